@@ -3,8 +3,7 @@ package main
 //go:generate fyne package
 
 import (
-	"bufio"
-	"encoding/csv"
+	"context"
 	"errors"
 	"fmt"
 	"fyne.io/fyne/v2"
@@ -13,19 +12,22 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
-	"github.com/mshafiee/progressbar"
 	"github.com/mshafiee/translate/cmd/translate-ui/data"
-	"github.com/mshafiee/translate/internal/gtranslate"
-	"github.com/mshafiee/translate/internal/po"
+	"github.com/mshafiee/translate/internal/job"
+	"github.com/mshafiee/translate/internal/ratelimit"
+	"github.com/mshafiee/translate/internal/retry"
+	"github.com/mshafiee/translate/internal/tm"
+	"github.com/mshafiee/translate/internal/translator"
+	_ "github.com/mshafiee/translate/internal/translator/deepl"
+	_ "github.com/mshafiee/translate/internal/translator/google"
+	_ "github.com/mshafiee/translate/internal/translator/libre"
+	_ "github.com/mshafiee/translate/internal/translator/microsoft"
+	_ "github.com/mshafiee/translate/internal/translator/openai"
 	"github.com/mshafiee/translate/internal/utils"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
-	"runtime"
-	"strconv"
 	"strings"
-	"sync"
 	"time"
 )
 
@@ -78,13 +80,31 @@ func main() {
 		dialog.Show()
 	})
 
-	toCombo := widget.NewSelect(languageNames, func(s string) {})
-	toCombo.SetSelected("Persian")
+	toCheck := widget.NewCheckGroup(languageNames[1:], nil)
+	toCheck.SetSelected([]string{"Persian"})
+	toScroll := container.NewVScroll(toCheck)
+	toScroll.SetMinSize(fyne.NewSize(0, 120))
+
+	providerCombo := widget.NewSelect(translator.Names(), func(s string) {})
+	providerCombo.SetSelected("google")
+
+	providerURLEntry := widget.NewEntry()
+	providerURLEntry.SetPlaceHolder("Provider URL (optional)")
+
+	apiKeyEntry := widget.NewPasswordEntry()
+	apiKeyEntry.SetPlaceHolder("API key (optional)")
 
 	retranslationCheck := widget.NewCheck("Retranslate separate sentences", nil)
 
-	progressBar := widget.NewProgressBar()
-	progressBar.Hide()
+	memoryCheck := widget.NewCheck("Use translation memory", nil)
+	memoryCheck.SetChecked(true)
+
+	resumeCheck := widget.NewCheck("Resume previous job", nil)
+
+	fuzzySelect := widget.NewSelect([]string{fuzzyOff, fuzzyComment, fuzzyDraft}, nil)
+	fuzzySelect.SetSelected(fuzzyComment)
+
+	progressBox := container.NewVBox()
 
 	outputMultiLineEntry := widget.NewMultiLineEntry()
 	outputMultiLineEntry.Text = fmt.Sprintf("%s\n%s\n", "Project: https://github.com/mshafiee/translate", "Developed by muhammad.shafiee@gmail.com, 2023")
@@ -105,18 +125,21 @@ func main() {
 			ctrl <- true
 			break
 		case "Translate":
-			from, to := getLanguageFromTo(fromCombo, toCombo)
+			from, to := getLanguageFromTo(fromCombo, toCheck)
 			input := inputEntry.Text
 			output := outputEntry.Text
+			providerConfig := translator.Config{
+				BaseURL: providerURLEntry.Text,
+				APIKey:  apiKeyEntry.Text,
+			}
+			provider := providerCombo.Selected
 			go func() {
-				translate(ctrl, outputMultiLineEntryWriter, progressBar, from, input, output, to, retranslationCheck.Checked)
+				translate(ctrl, outputMultiLineEntryWriter, progressBox, provider, providerConfig, from, input, output, to, retranslationCheck.Checked, memoryCheck.Checked, fuzzySelect.Selected, resumeCheck.Checked)
 				translateButton.Enable()
 				controlButton.Disable()
-				progressBar.Hide()
 			}()
 			translateButton.Disable()
 			controlButton.Enable()
-			progressBar.Show()
 			break
 		}
 	})
@@ -143,8 +166,11 @@ func main() {
 	})
 	controlButton.Disable()
 
-	inputEntry.OnChanged = validate(outputMultiLineEntryWriter, fromCombo, inputEntry, outputEntry, toCombo, translateButton)
-	outputEntry.OnChanged = validate(outputMultiLineEntryWriter, fromCombo, inputEntry, outputEntry, toCombo, translateButton)
+	inputEntry.OnChanged = validate(outputMultiLineEntryWriter, fromCombo, inputEntry, outputEntry, toCheck, translateButton)
+	outputEntry.OnChanged = validate(outputMultiLineEntryWriter, fromCombo, inputEntry, outputEntry, toCheck, translateButton)
+	toCheck.OnChanged = func([]string) {
+		validate(outputMultiLineEntryWriter, fromCombo, inputEntry, outputEntry, toCheck, translateButton)("")
+	}
 
 	formContainer := container.New(
 		layout.NewFormLayout(),
@@ -155,17 +181,29 @@ func main() {
 		widget.NewLabel("Output folder:"),
 		container.New(layout.NewBorderLayout(nil, nil, nil, outputButton), outputEntry, outputButton),
 		widget.NewLabel("To:"),
-		toCombo,
+		toScroll,
+		widget.NewLabel("Provider:"),
+		providerCombo,
+		widget.NewLabel("Provider URL:"),
+		providerURLEntry,
+		widget.NewLabel("API key:"),
+		apiKeyEntry,
 		layout.NewSpacer(),
 		retranslationCheck,
 		layout.NewSpacer(),
+		memoryCheck,
+		widget.NewLabel("Fuzzy matches:"),
+		fuzzySelect,
+		layout.NewSpacer(),
+		resumeCheck,
+		layout.NewSpacer(),
 		container.New(
 			layout.NewGridLayout(2),
 			translateButton,
 			controlButton,
 		),
 		layout.NewSpacer(),
-		progressBar,
+		progressBox,
 	)
 
 	mainContainer := container.New(
@@ -178,14 +216,17 @@ func main() {
 	w.ShowAndRun()
 }
 
-func getLanguageFromTo(fromCombo *widget.Select, toCombo *widget.Select) (string, string) {
-	var from, to string
+func getLanguageFromTo(fromCombo *widget.Select, toCheck *widget.CheckGroup) (string, []string) {
+	var from string
+	var to []string
 	for _, l := range languages {
 		if l[0] == fromCombo.Selected {
 			from = l[1]
 		}
-		if l[0] == toCombo.Selected {
-			to = l[1]
+		for _, selected := range toCheck.Selected {
+			if l[0] == selected {
+				to = append(to, l[1])
+			}
 		}
 	}
 	return from, to
@@ -200,15 +241,15 @@ func getLanguageNames() []string {
 	return languageNames
 }
 
-func validate(w io.Writer, fromCombo *widget.Select, inputEntry *widget.Entry, outputEntry *widget.Entry, toCombo *widget.Select, translateButton *widget.Button) func(_ string) {
+func validate(w io.Writer, fromCombo *widget.Select, inputEntry *widget.Entry, outputEntry *widget.Entry, toCheck *widget.CheckGroup, translateButton *widget.Button) func(_ string) {
 	return func(_ string) {
 		from := fromCombo.Selected
 		input := inputEntry.Text
 		output := outputEntry.Text
-		to := toCombo.Selected
+		to := toCheck.Selected
 
 		// Check if any field is empty
-		if from == "" || input == "" || output == "" || to == "" {
+		if from == "" || input == "" || output == "" || len(to) == 0 {
 			translateButton.Disable()
 			return
 		}
@@ -216,7 +257,7 @@ func validate(w io.Writer, fromCombo *widget.Select, inputEntry *widget.Entry, o
 	}
 }
 
-func translate(ctrl <-chan bool, w io.Writer, progressBarUI *widget.ProgressBar, translateFrom string, inputFilePath string, outputFolder string, translateTo string, doRetranslation bool) {
+func translate(ctrl <-chan bool, w io.Writer, progressBox *fyne.Container, provider string, providerConfig translator.Config, translateFrom string, inputFilePath string, outputFolder string, translateTo []string, doRetranslation bool, useMemory bool, fuzzyMode string, resume bool) {
 	// Validate input parameters
 	if inputFilePath == "" {
 		exitWithError(w, errors.New("missing required input file path"))
@@ -226,7 +267,7 @@ func translate(ctrl <-chan bool, w io.Writer, progressBarUI *widget.ProgressBar,
 		exitWithError(w, errors.New("missing required 'from' language code"))
 		return
 	}
-	if translateTo == "" {
+	if len(translateTo) == 0 {
 		exitWithError(w, errors.New("missing required 'to' language code"))
 		return
 	}
@@ -235,9 +276,60 @@ func translate(ctrl <-chan bool, w io.Writer, progressBarUI *widget.ProgressBar,
 		return
 	}
 
+	limits := ratelimit.For(provider, ratelimit.Limits{})
+	concurrency := ratelimit.NewAdaptive(limits)
+	concurrency.OnChange = func(limit int) {
+		fmt.Fprintf(w, "%s - concurrency limit changed to %d\n", time.Now().Format("2006-01-02 15:04"), limit)
+	}
+
+	limiter := ratelimit.NewLimiter(limits)
+	retryPolicy := retry.DefaultPolicy()
+	retryPolicy.Throttle = limiter.Throttle
+	retryPolicy.OnAttempt = func(a retry.Attempt) {
+		fmt.Fprintf(w, "%s - %s\n", time.Now().Format("2006-01-02 15:04"), a)
+		concurrency.Observe(a)
+	}
+	providerConfig.Retry = &retryPolicy
+
+	tr, err := translator.New(provider, providerConfig)
+	if err != nil {
+		exitWithError(w, err)
+		return
+	}
+	tr = ratelimit.Wrap(tr, limiter)
+
+	var memoryTranslator *tm.Translator
+	if useMemory {
+		memoryPath, err := tm.DefaultPath()
+		if err != nil {
+			exitWithError(w, err)
+			return
+		}
+		memory, err := tm.Open(memoryPath)
+		if err != nil {
+			exitWithError(w, err)
+			return
+		}
+		defer memory.Close()
+		memoryTranslator = tm.Wrap(tr, memory)
+		if fuzzyMode != fuzzyOff {
+			memoryTranslator.FuzzyThreshold = tm.DefaultFuzzyThreshold
+			memoryTranslator.FuzzyDraft = fuzzyMode == fuzzyDraft
+		}
+		tr = memoryTranslator
+	}
+
+	// Translate repeated lines and sentences only once.
+	dedup := job.NewDedup(tr)
+	tr = dedup
+
 	fmt.Fprintf(w, "---\nSource: %s\n", inputFilePath)
 	fmt.Fprintf(w, "Translation files path: %s\n", outputFolder)
+	fmt.Fprintf(w, "Target languages: %s\n", strings.Join(translateTo, ", "))
+	fmt.Fprintf(w, "Provider: %s\n", tr.Name())
 	fmt.Fprintf(w, "Retranslation: %v\n", doRetranslation)
+	fmt.Fprintf(w, "Translation memory: %v\n", useMemory)
+	fmt.Fprintf(w, "Fuzzy matches: %s\n", fuzzyMode)
 	fmt.Fprintf(w, "Start Time: %s\n", time.Now().Format("2006-01-02 15:04:05"))
 
 	inputFileNameWithoutExt := filepath.Base(inputFilePath[:len(inputFilePath)-len(filepath.Ext(inputFilePath))])
@@ -246,6 +338,7 @@ func translate(ctrl <-chan bool, w io.Writer, progressBarUI *widget.ProgressBar,
 	totalLineNumber, err := utils.CountLines(inputFilePath)
 	if err != nil {
 		exitWithError(w, err)
+		return
 	}
 
 	// Open the input file.
@@ -256,176 +349,99 @@ func translate(ctrl <-chan bool, w io.Writer, progressBarUI *widget.ProgressBar,
 	}
 	defer file.Close()
 
-	err = os.MkdirAll(outputFolder, os.ModePerm)
-	if err != nil {
-		fmt.Fprintln(w, "Error:", err)
-		return
+	// Start a job for each target language, or pick up the previous ones
+	// when resuming. Several languages are written to subfolders of the
+	// output folder.
+	settings := job.Settings{From: translateFrom, Provider: provider, Sentences: doRetranslation}
+	var targets []*job.Target
+	for _, to := range translateTo {
+		settings.To = to
+		target, err := job.OpenTarget(job.TargetFolder(outputFolder, to, len(translateTo)), inputFilePath, inputFileNameWithoutExt, settings, resume)
+		if err != nil {
+			exitWithError(w, err)
+			return
+		}
+		defer target.Close()
+		if resume {
+			fmt.Fprintf(w, "Resuming previous %s job, %d lines already translated\n", to, target.Manifest.Completed)
+		}
+		targets = append(targets, target)
 	}
 
-	intermediateFileName := fmt.Sprintf("%s/%s", outputFolder, fmt.Sprintf("%s-intermed.csv", inputFileNameWithoutExt))
-
-	// Create the output file.
-	intermediateFile, err := os.Create(intermediateFileName)
-	if err != nil {
-		fmt.Fprintln(w, "Error:", err)
-		return
+	// Show the progress of each target language.
+	progressBars := make(map[*job.Target]*widget.ProgressBar)
+	progressBox.RemoveAll()
+	for _, target := range targets {
+		bar := widget.NewProgressBar()
+		to := target.To
+		bar.TextFormatter = func() string {
+			return fmt.Sprintf("%s %.0f%%", to, bar.Value*100)
+		}
+		bar.SetValue(float64(target.Written()) / float64(totalLineNumber))
+		progressBars[target] = bar
+		progressBox.Add(bar)
 	}
-	defer intermediateFile.Close()
-
-	// Create a CSV writer.
-	writer := csv.NewWriter(intermediateFile)
-
-	// Create a scanner to read the file line by line.
-	scanner := bufio.NewScanner(file)
-
-	// Create a WaitGroup to wait for all goroutines to finish.
-	var wg sync.WaitGroup
-
-	// Create a channel to limit the number of concurrent goroutines.
-	maxConcurrency := runtime.NumCPU() * 4
-	concurrency := make(chan struct{}, maxConcurrency)
-
-	lineNumber := 0
+	defer progressBox.RemoveAll()
 
-	// Process each line in a separate goroutine.
-	for scanner.Scan() {
+	// Read the input once and translate it into every target language,
+	// pausing or canceling between lines when asked to.
+	engine := job.NewEngine(tr, concurrency, translateFrom)
+	engine.Sentences = doRetranslation
+	engine.Hold = func() error {
 		select {
 		case paused := <-ctrl:
-			// pause or cancel the goroutine
 			if paused {
 				fmt.Fprintf(w, "%s - pausing...\n", time.Now().Format("2006-01-02 15:04"))
-				ctrlSignal := <-ctrl // wait for resume signal
-				if ctrlSignal {
+				if <-ctrl { // wait for resume signal
 					fmt.Fprintf(w, "%s - resumed.\n", time.Now().Format("2006-01-02 15:04"))
-				} else {
-					fmt.Fprintf(w, "%s - canceled.\n", time.Now().Format("2006-01-02 15:04"))
-
-					// Wait for all goroutines to finish.
-					wg.Wait()
-
-					// Flush any remaining data to the CSV file.
-					writer.Flush()
-
-					// exit the translate function
-					return
+					return nil
 				}
 			}
+			return errCanceled
 		default:
-			lineNumber++
-
-			// Acquire a slot in the concurrency channel.
-			concurrency <- struct{}{}
-
-			// Increment the WaitGroup lineNumber.
-			wg.Add(1)
-
-			go consumer(w, concurrency, &wg, progressBarUI, totalLineNumber, lineNumber, scanner.Text(), translateFrom, translateTo, doRetranslation, writer)
+			return nil
 		}
 	}
-
-	// Wait for all goroutines to finish.
-	wg.Wait()
-
-	// Flush any remaining data to the CSV file.
-	writer.Flush()
-
-	progressbar.ColorArrowProgressBar(100, 100)
-	normalizedCommasFileName := fmt.Sprintf("%s/%s-normalized.csv", outputFolder, inputFileNameWithoutExt)
-	sortedFileName := fmt.Sprintf("%s/%s-sorted.csv", outputFolder, inputFileNameWithoutExt)
-	translatedTextFileName := fmt.Sprintf("%s/%s-%s.txt", outputFolder, inputFileNameWithoutExt, translateTo)
-	poFileName := fmt.Sprintf("%s/%s.po", outputFolder, inputFileNameWithoutExt)
-	postProccess(w, intermediateFileName, normalizedCommasFileName, sortedFileName, translatedTextFileName, poFileName, 3)
-}
-
-var mutex = &sync.Mutex{}
-
-// Consumer function that consumes elements of the buffer and writes them to a CSV file.
-func consumer(w io.Writer, concurrency chan struct{}, wg *sync.WaitGroup, progressBarUI *widget.ProgressBar, totalRows, rowID int, originalText, translateFrom, translateTo string, doRetranslation bool, writer *csv.Writer) {
-	// Release the slot in the concurrency channel when done.
-	defer func() { <-concurrency }()
-	defer wg.Done()
-
-	// Create a slice to store the paragraphs.
-	var paragraphs [][]string
-
-	if len(strings.TrimSpace(originalText)) > 0 {
-		googleTranslated, err := gtranslate.TranslateWithParams(
-			originalText,
-			gtranslate.TranslationParams{
-				From: translateFrom,
-				To:   translateTo,
-			},
-		)
-		if err != nil {
-			fmt.Fprintln(w, err)
+	engine.OnError = func(err error) {
+		fmt.Fprintln(w, err)
+	}
+	engine.OnProgress = func(target *job.Target) {
+		progressBars[target].SetValue(float64(target.Written()) / float64(totalLineNumber))
+	}
+	if err := engine.Run(context.Background(), file, targets); err != nil {
+		if errors.Is(err, errCanceled) {
+			fmt.Fprintf(w, "%s - canceled.\n", time.Now().Format("2006-01-02 15:04"))
+			return
 		}
-		progressBarUI.SetValue(float64(rowID) / float64(totalRows))
-		progressbar.ColorArrowProgressBar(rowID, totalRows)
+		exitWithError(w, err)
+		return
+	}
 
-		row := []string{
-			strconv.Itoa(rowID),
-			originalText,
-		}
-		//row = append(row, googleTranslated...)
-		row = append(row, googleTranslated[0])
-
-		if doRetranslation {
-			sentence, err := gtranslate.SentenceWithParams(
-				originalText,
-				gtranslate.TranslationParams{
-					From: translateFrom,
-					To:   translateTo,
-				},
-			)
-			if err != nil {
-
-				fmt.Fprintln(w, err)
-			}
-			row = append(row, sentence...)
+	// Flush and close the output files.
+	for _, target := range targets {
+		if err := target.Close(); err != nil {
+			exitWithError(w, err)
+			return
 		}
-		// Add the pair to the slice.
-		paragraphs = append(paragraphs, row)
 	}
 
-	// Write the paragraphs to the CSV file.
-	if len(paragraphs) > 0 {
-		mutex.Lock()
-		defer mutex.Unlock()
-
-		for _, pair := range paragraphs {
-			// Insert the paragraph number into the first column.
-			var record []string
-			for _, s := range pair {
-				record = append(record, s)
-			}
-			if err := writer.Write(record); err != nil {
-				log.Println("Error:", err)
-				return
-			}
-		}
-		writer.Flush()
+	if memoryTranslator != nil {
+		stats := memoryTranslator.Stats()
+		fmt.Fprintf(w, "Translation memory: %d segments reused, %d fuzzy drafts, %d sent to %s\n", stats.Hits, stats.Fuzzy, stats.Misses, provider)
 	}
+	dedupStats := dedup.Stats()
+	fmt.Fprintf(w, "Deduplication: %d of %d segments repeated earlier ones and were not translated again\n", dedupStats.Repeated, dedupStats.Segments)
 }
 
-func postProccess(w io.Writer, inputFileName, normalizedCommasFileName, sortedFileName, translatedTextFileName, poFileName string, columnNumber int) {
-	err := utils.AddCommasToFile(inputFileName, normalizedCommasFileName)
-	if err != nil {
-		exitWithError(w, fmt.Errorf("Error AddCommasToCSV: %v\n", err))
-	}
+// Choices of the fuzzy matches select.
+const (
+	fuzzyOff     = "Off"
+	fuzzyComment = "Translator comment"
+	fuzzyDraft   = "Draft translation"
+)
 
-	err = utils.NumericalSortCSV(normalizedCommasFileName, sortedFileName)
-	if err != nil {
-		exitWithError(w, fmt.Errorf("Error NumericalSortCSV: %v\n", err))
-	}
-	err = utils.ExtractColumnWithEmptyRows(sortedFileName, translatedTextFileName, columnNumber)
-	if err != nil {
-		exitWithError(w, fmt.Errorf("Error ExtractColumnWithEmptyRows: %v\n", err))
-	}
-	err = po.CSVtoPo(sortedFileName, poFileName)
-	if err != nil {
-		exitWithError(w, fmt.Errorf("Error converting CSV to PO: %v\n", err))
-	}
-}
+// errCanceled stops a job canceled by the user.
+var errCanceled = errors.New("translation canceled")
 
 func exitWithError(w io.Writer, err error) {
 	fmt.Fprintln(w, err)
@@ -1,212 +1,208 @@
 package main
 
 import (
-	"bufio"
-	"encoding/csv"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"github.com/mshafiee/progressbar"
-	"github.com/mshafiee/translate/internal/gtranslate"
-	"github.com/mshafiee/translate/internal/po"
+	"github.com/mshafiee/translate/internal/job"
+	"github.com/mshafiee/translate/internal/output"
+	_ "github.com/mshafiee/translate/internal/translator/deepl"
+	_ "github.com/mshafiee/translate/internal/translator/google"
+	_ "github.com/mshafiee/translate/internal/translator/libre"
+	_ "github.com/mshafiee/translate/internal/translator/microsoft"
+	_ "github.com/mshafiee/translate/internal/translator/openai"
 	"github.com/mshafiee/translate/internal/utils"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"strconv"
 	"strings"
-	"sync"
 )
 
-const MAX_CONCURRENCY = 10
-
 func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run runs the command given by args. Errors are returned rather than
+// exiting, so deferred calls close the outputs first.
+func run(args []string) error {
+	if len(args) > 0 && args[0] == "tm" {
+		return runTM(args[1:])
+	}
+	if len(args) > 0 && args[0] == "po" {
+		return runPO(args[1:])
+	}
+	return runTranslate(args)
+}
+
+// runTranslate translates a text file into one or more languages.
+func runTranslate(args []string) error {
 	var (
 		inputFilePath string
 		translateFrom string
 		translateTo   string
 		outputFolder  string
+		contextLines  int
+		resume        bool
+		batchLines    int
+		providerFlags providerFlags
 	)
 	// Define flags for command-line arguments
 	flag.StringVar(&inputFilePath, "input", "", "Path to the input file for translation")
 	flag.StringVar(&translateFrom, "from", "", "Language code to translate from (ISO 639-1) e.g: en")
-	flag.StringVar(&translateTo, "to", "", "Language code to translate to (ISO 639-1) e.g: fa")
+	flag.StringVar(&translateTo, "to", "", "Language codes to translate to (ISO 639-1), separated by commas e.g: fa,ar,de")
 	flag.StringVar(&outputFolder, "output", "", "Folder to store translated files")
-	flag.Parse()
+	flag.IntVar(&contextLines, "context-lines", job.DefaultContextLines, "Number of surrounding lines of the same paragraph sent to context aware providers")
+	flag.IntVar(&batchLines, "batch", job.DefaultBatchLines, "Maximum number of consecutive lines of a paragraph sent in one request")
+	flag.BoolVar(&resume, "resume", false, "Resume the previous job in the output folder, skipping lines it already translated")
+	providerFlags.register(flag.CommandLine)
+	flag.CommandLine.Parse(args)
 
 	// Validate input parameters
 	if inputFilePath == "" {
-		exitWithError(errors.New("missing required input file path"))
+		return errors.New("missing required input file path")
 	}
 	if translateFrom == "" {
-		exitWithError(errors.New("missing required 'from' language code"))
+		return errors.New("missing required 'from' language code")
 	}
-	if translateTo == "" {
-		exitWithError(errors.New("missing required 'to' language code"))
+	targetLanguages := splitList(translateTo)
+	if len(targetLanguages) == 0 {
+		return errors.New("missing required 'to' language code")
 	}
 	if outputFolder == "" {
-		exitWithError(errors.New("missing required output folder path"))
+		return errors.New("missing required output folder path")
+	}
+	if batchLines < 1 || batchLines > output.DefaultWindow {
+		return fmt.Errorf("batch must be between 1 and %d lines", output.DefaultWindow)
 	}
 
+	stack, err := providerFlags.open()
+	if err != nil {
+		return err
+	}
+	defer stack.Close()
+
 	inputFileNameWithoutExt := filepath.Base(inputFilePath[:len(inputFilePath)-len(filepath.Ext(inputFilePath))])
 
 	totalLineNumber, err := utils.CountLines(inputFilePath)
 	if err != nil {
-		exitWithError(err)
+		return err
 	}
 
 	// Open the input file.
 	file, err := os.Open(inputFilePath)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return
+		return err
 	}
 	defer file.Close()
 
-	err = os.MkdirAll(outputFolder, os.ModePerm)
-	if err != nil {
-		log.Println(err)
+	// Start a job for each target language, or pick up the previous ones
+	// when resuming. Several languages are written to subfolders of the
+	// output folder.
+	settings := job.Settings{From: translateFrom, Provider: providerFlags.provider, Sentences: true}
+	var targets []*job.Target
+	for _, to := range targetLanguages {
+		settings.To = to
+		target, err := job.OpenTarget(job.TargetFolder(outputFolder, to, len(targetLanguages)), inputFilePath, inputFileNameWithoutExt, settings, resume)
+		if err != nil {
+			return err
+		}
+		defer target.Close()
+		if resume {
+			fmt.Printf("Resuming previous %s job, %d lines already translated\n", to, target.Manifest.Completed)
+		}
+		targets = append(targets, target)
 	}
 
-	intermediateFileName := fmt.Sprintf("%s/%s", outputFolder, fmt.Sprintf("%s-intermed.csv", inputFileNameWithoutExt))
+	// Stop at the first interrupt, keeping the lines translated so far.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	// Create the output file.
-	intermediateFile, err := os.Create(intermediateFileName)
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+	// Read the input once and translate it into every target language.
+	engine := job.NewEngine(stack.tr, stack.concurrency, translateFrom)
+	engine.Sentences = true
+	engine.BatchLines = batchLines
+	engine.ContextLines = contextLines
+	engine.OnError = func(err error) {
+		log.Println(err)
 	}
-	defer intermediateFile.Close()
-
-	// Create a CSV writer.
-	writer := csv.NewWriter(intermediateFile)
-
-	// Create a scanner to read the file line by line.
-	scanner := bufio.NewScanner(file)
-
-	// Create a WaitGroup to wait for all goroutines to finish.
-	var wg sync.WaitGroup
-
-	// Create a channel to limit the number of concurrent goroutines.
-	concurrency := make(chan struct{}, MAX_CONCURRENCY)
-
-	lineNumber := 0
-
-	// Process each line in a separate goroutine.
-	for scanner.Scan() {
-		lineNumber++
-
-		// Acquire a slot in the concurrency channel.
-		concurrency <- struct{}{}
-
-		// Increment the WaitGroup lineNumber.
-		wg.Add(1)
-
-		go consumer(concurrency, &wg, totalLineNumber, lineNumber, scanner.Text(), translateFrom, translateTo, writer)
-
+	engine.OnProgress = func(*job.Target) {
+		showProgress(targets, totalLineNumber)
 	}
-
-	// Wait for all goroutines to finish.
-	wg.Wait()
-
-	// Flush any remaining data to the CSV file.
-	writer.Flush()
-
-	progressbar.ColorArrowProgressBar(100, 100)
-	intermediateFile.Close()
-	normalizedCommasFileName := fmt.Sprintf("%s/%s-normalized.csv", outputFolder, inputFileNameWithoutExt)
-	sortedFileName := fmt.Sprintf("%s/%s-sorted.csv", outputFolder, inputFileNameWithoutExt)
-	translatedTextFileName := fmt.Sprintf("%s/%s-%s.txt", outputFolder, inputFileNameWithoutExt, translateTo)
-	poFileName := fmt.Sprintf("%s/%s.po", outputFolder, inputFileNameWithoutExt)
-	postProccess(intermediateFileName, normalizedCommasFileName, sortedFileName, translatedTextFileName, poFileName, 3)
-}
-
-var mutex = &sync.Mutex{}
-
-// Consumer function that consumes elements of the buffer and writes them to a CSV file.
-func consumer(concurrency chan struct{}, wg *sync.WaitGroup, totalRows, rowID int, originalText, translateFrom, translateTo string, writer *csv.Writer) {
-	// Release the slot in the concurrency channel when done.
-	defer func() { <-concurrency }()
-	defer wg.Done()
-
-	// Create a slice to store the paragraphs.
-	var paragraphs [][]string
-
-	if len(strings.TrimSpace(originalText)) > 0 {
-		googleTranslated, err := gtranslate.TranslateWithParams(
-			originalText,
-			gtranslate.TranslationParams{
-				From: "en",
-				To:   "fa",
-			},
-		)
-		if err != nil {
-			panic(err)
+	if err := engine.Run(ctx, file, targets); err != nil {
+		if ctx.Err() != nil {
+			err = errors.New("\ninterrupted, run again with -resume to translate the remaining lines")
 		}
-		//log.Println("processing line:", rowID, totalRows)
-		progressbar.ColorArrowProgressBar(rowID, totalRows)
+		return err
+	}
 
-		row := []string{
-			strconv.Itoa(rowID),
-			originalText,
+	// Flush and close the output files.
+	for _, target := range targets {
+		if err := target.Close(); err != nil {
+			return err
 		}
-		//row = append(row, googleTranslated...)
-		row = append(row, googleTranslated[0])
-
-		sentence, err := gtranslate.SentenceWithParams(
-			originalText,
-			gtranslate.TranslationParams{
-				From: translateFrom,
-				To:   translateTo,
-			},
-		)
-		row = append(row, sentence...)
-
-		// Add the pair to the slice.
-		paragraphs = append(paragraphs, row)
-	}
-
-	// Write the paragraphs to the CSV file.
-	if len(paragraphs) > 0 {
-		mutex.Lock()
-		defer mutex.Unlock()
-
-		for _, pair := range paragraphs {
-			// Insert the paragraph number into the first column.
-			var record []string
-			for _, s := range pair {
-				record = append(record, s)
-			}
-			if err := writer.Write(record); err != nil {
-				fmt.Println("Error:", err)
-				return
-			}
+	}
+
+	showProgress(targets, totalLineNumber)
+	stack.printStats(providerFlags.provider)
+	for _, target := range targets {
+		if line := target.Failed(); line != 0 {
+			fmt.Printf("%s: line %d was left untranslated, run again with -resume to translate it and the following lines again\n", target.To, line)
 		}
-		writer.Flush()
 	}
+	return nil
 }
 
-func postProccess(inputFileName, normalizedCommasFileName, sortedFileName, translatedTextFileName, poFileName string, columnNumber int) {
-	err := utils.AddCommasToFile(inputFileName, normalizedCommasFileName)
-	if err != nil {
-		exitWithError(fmt.Errorf("Error AddCommasToCSV: %v\n", err))
+// showProgress draws the progress bar of the job, or the share of lines
+// written in each language when translating into several.
+func showProgress(targets []*job.Target, totalRows int) {
+	if len(targets) == 1 {
+		progressbar.ColorArrowProgressBar(targets[0].Written(), totalRows)
+		return
 	}
-
-	err = utils.NumericalSortCSV(normalizedCommasFileName, sortedFileName)
-	if err != nil {
-		exitWithError(fmt.Errorf("Error NumericalSortCSV: %v\n", err))
+	var sb strings.Builder
+	for _, target := range targets {
+		fmt.Fprintf(&sb, "%s %6.2f%%  ", target.To, 100*float64(target.Written())/float64(totalRows))
 	}
-	err = utils.ExtractColumnWithEmptyRows(sortedFileName, translatedTextFileName, columnNumber)
-	if err != nil {
-		exitWithError(fmt.Errorf("Error ExtractColumnWithEmptyRows: %v\n", err))
+	fmt.Printf("\r%s", sb.String())
+}
+
+// splitList returns the distinct non-empty items of a comma separated list.
+func splitList(list string) []string {
+	var items []string
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		items = append(items, item)
 	}
-	err = po.CSVtoPo(sortedFileName, poFileName)
-	if err != nil {
-		exitWithError(fmt.Errorf("Error converting CSV to PO: %v\n", err))
+	return items
+}
+
+// optionsFlag collects repeated key=value flags.
+type optionsFlag map[string]string
+
+func (o optionsFlag) String() string {
+	var pairs []string
+	for k, v := range o {
+		pairs = append(pairs, k+"="+v)
 	}
+	return strings.Join(pairs, ",")
 }
 
-func exitWithError(err error) {
-	fmt.Fprintln(os.Stderr, err)
-	os.Exit(1)
+func (o optionsFlag) Set(value string) error {
+	k, v, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("option %q is not in key=value form", value)
+	}
+	o[k] = v
+	return nil
 }
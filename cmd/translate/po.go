@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mshafiee/translate/internal/job"
+	"github.com/mshafiee/translate/internal/po"
+)
+
+// runPO implements "translate po", filling the missing translations of a
+// gettext catalog.
+func runPO(args []string) error {
+	var (
+		translateFrom    string
+		translateTo      string
+		outputPath       string
+		retranslateFuzzy bool
+		keepHeader       bool
+		width            int
+		batchLines       int
+		providerFlags    providerFlags
+	)
+	flags := flag.NewFlagSet("po", flag.ExitOnError)
+	flags.StringVar(&translateFrom, "from", "", "Language code of the messages (ISO 639-1) e.g: en")
+	flags.StringVar(&translateTo, "to", "", "Language code to translate to (ISO 639-1) e.g: fa")
+	flags.StringVar(&outputPath, "output", "", "Path of the translated catalog (default: the input .po itself, or <to>.po next to an input .pot)")
+	flags.BoolVar(&retranslateFuzzy, "retranslate-fuzzy", false, "Translate fuzzy entries again, not only untranslated ones")
+	flags.BoolVar(&keepHeader, "keep-header", false, "Keep the header of the catalog as it is instead of updating its language, plural forms, revision date, translator and generator")
+	flags.IntVar(&width, "width", po.DefaultWidth, "Maximum line width of the written catalog, 0 disables wrapping")
+	flags.IntVar(&batchLines, "batch", job.DefaultBatchLines, "Maximum number of messages sent in one request")
+	providerFlags.register(flags)
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: translate po -from <code> -to <code> [flags] catalog.po|catalog.pot")
+	}
+	inputPath := flags.Arg(0)
+	if translateFrom == "" {
+		return errors.New("missing required 'from' language code")
+	}
+	if translateTo == "" {
+		return errors.New("missing required 'to' language code")
+	}
+	if batchLines < 1 {
+		return errors.New("batch must be at least 1 message")
+	}
+	if outputPath == "" {
+		outputPath = inputPath
+		if strings.EqualFold(filepath.Ext(inputPath), ".pot") {
+			outputPath = filepath.Join(filepath.Dir(inputPath), translateTo+".po")
+		}
+	}
+
+	entries, err := po.ReadFile(inputPath)
+	if err != nil {
+		return err
+	}
+
+	stack, err := providerFlags.open()
+	if err != nil {
+		return err
+	}
+	defer stack.Close()
+
+	// Stop at the first interrupt, keeping the messages translated so far.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	engine := job.NewEngine(stack.tr, stack.concurrency, translateFrom)
+	engine.BatchLines = batchLines
+	engine.OnError = func(err error) {
+		log.Println(err)
+	}
+	if !keepHeader {
+		settings := job.Settings{From: translateFrom, To: translateTo, Provider: providerFlags.provider}
+		header := job.HeaderFor(settings, "", time.Time{})
+		if job.HasPlurals(entries) {
+			if header.PluralForms, err = job.CatalogPluralForms(entries, translateTo); err != nil {
+				log.Println(err)
+			}
+		}
+		entries = po.SetHeader(entries, header)
+	}
+	translated, runErr := engine.TranslateCatalog(ctx, entries, translateTo, retranslateFuzzy)
+
+	// Write what was translated even when interrupted.
+	if err := po.WriteFile(outputPath, entries, width); err != nil {
+		return err
+	}
+	fmt.Printf("Translated %d entries into %s, written to %s", translated, translateTo, outputPath)
+	stack.printStats(providerFlags.provider)
+	if runErr != nil {
+		if ctx.Err() != nil {
+			runErr = errors.New("interrupted, run again to translate the remaining messages")
+		}
+		return runErr
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mshafiee/translate/internal/job"
+	"github.com/mshafiee/translate/internal/ratelimit"
+	"github.com/mshafiee/translate/internal/retry"
+	"github.com/mshafiee/translate/internal/tm"
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+// providerFlags select the translation provider of a job and how it is
+// called, for every command translating text.
+type providerFlags struct {
+	provider     string
+	providerURL  string
+	apiKey       string
+	maxAttempts  int
+	limits       ratelimit.Limits
+	memoryPath   string
+	noMemory     bool
+	fuzzy        float64
+	fuzzyDraft   bool
+	providerOpts optionsFlag
+}
+
+// register defines the flags in flags.
+func (p *providerFlags) register(flags *flag.FlagSet) {
+	p.providerOpts = optionsFlag{}
+	flags.StringVar(&p.provider, "provider", "google", fmt.Sprintf("Translation provider, one of %v", translator.Names()))
+	flags.StringVar(&p.providerURL, "provider-url", "", "Base URL of the translation provider, e.g. a self-hosted LibreTranslate")
+	flags.StringVar(&p.apiKey, "api-key", os.Getenv("TRANSLATE_API_KEY"), "API key of the translation provider (default $TRANSLATE_API_KEY)")
+	flags.IntVar(&p.maxAttempts, "max-attempts", retry.DefaultPolicy().MaxAttempts, "Maximum number of attempts per request, including retries")
+	flags.Float64Var(&p.limits.RequestsPerSecond, "rps", 0, "Maximum requests per second (default depends on the provider)")
+	flags.IntVar(&p.limits.CharsPerMinute, "chars-per-minute", 0, "Maximum characters sent per minute (default depends on the provider)")
+	flags.IntVar(&p.limits.MaxConcurrency, "concurrency", 0, "Maximum number of concurrent requests; shrinks automatically when throttled (default depends on the provider)")
+	defaultMemoryPath, _ := tm.DefaultPath()
+	flags.StringVar(&p.memoryPath, "tm", defaultMemoryPath, "Path to the translation memory database")
+	flags.BoolVar(&p.noMemory, "no-tm", false, "Do not read or update the translation memory")
+	flags.Float64Var(&p.fuzzy, "fuzzy", tm.DefaultFuzzyThreshold, "Minimum similarity in percent of translation memory fuzzy matches, 0 disables them")
+	flags.BoolVar(&p.fuzzyDraft, "fuzzy-draft", false, "Use fuzzy matches as draft translations instead of adding them as translator comments")
+	flags.Var(p.providerOpts, "provider-opt", "Provider specific option as key=value, may be repeated")
+}
+
+// translatorStack is the provider wrapped in retries, rate limiting, the
+// translation memory and deduplication.
+type translatorStack struct {
+	tr          translator.Translator
+	concurrency *ratelimit.Adaptive
+	memory      *tm.Memory
+	memoryTr    *tm.Translator
+	dedup       *job.Dedup
+}
+
+// open builds the translator stack the flags describe.
+func (p *providerFlags) open() (*translatorStack, error) {
+	limits := ratelimit.For(p.provider, p.limits)
+	s := &translatorStack{concurrency: ratelimit.NewAdaptive(limits)}
+	s.concurrency.OnChange = func(limit int) {
+		log.Println("concurrency limit changed to", limit)
+	}
+
+	limiter := ratelimit.NewLimiter(limits)
+	retryPolicy := retry.DefaultPolicy()
+	retryPolicy.MaxAttempts = p.maxAttempts
+	retryPolicy.Throttle = limiter.Throttle
+	retryPolicy.OnAttempt = func(a retry.Attempt) {
+		log.Println(a)
+		s.concurrency.Observe(a)
+	}
+
+	tr, err := translator.New(p.provider, translator.Config{
+		BaseURL: p.providerURL,
+		APIKey:  p.apiKey,
+		Retry:   &retryPolicy,
+		Options: p.providerOpts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	tr = ratelimit.Wrap(tr, limiter)
+
+	if !p.noMemory {
+		s.memory, err = tm.Open(p.memoryPath)
+		if err != nil {
+			return nil, err
+		}
+		s.memoryTr = tm.Wrap(tr, s.memory)
+		s.memoryTr.FuzzyThreshold = p.fuzzy
+		s.memoryTr.FuzzyDraft = p.fuzzyDraft
+		tr = s.memoryTr
+	}
+
+	// Translate repeated lines and sentences only once.
+	s.dedup = job.NewDedup(tr)
+	s.tr = s.dedup
+	return s, nil
+}
+
+// printStats prints how many segments the translation memory and
+// deduplication saved.
+func (s *translatorStack) printStats(provider string) {
+	if s.memoryTr != nil {
+		stats := s.memoryTr.Stats()
+		fmt.Printf("\nTranslation memory: %d segments reused, %d fuzzy drafts, %d sent to %s", stats.Hits, stats.Fuzzy, stats.Misses, provider)
+	}
+	dedupStats := s.dedup.Stats()
+	fmt.Printf("\nDeduplication: %d of %d segments repeated earlier ones and were not translated again\n", dedupStats.Repeated, dedupStats.Segments)
+}
+
+// Close closes the translation memory.
+func (s *translatorStack) Close() error {
+	if s.memory == nil {
+		return nil
+	}
+	return s.memory.Close()
+}
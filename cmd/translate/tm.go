@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mshafiee/translate/internal/tm"
+)
+
+// runTM implements the "translate tm" subcommands managing the translation
+// memory.
+func runTM(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: translate tm import|export [flags] file.tmx")
+	}
+
+	var (
+		memoryPath string
+		provider   string
+		from       string
+		to         string
+	)
+	defaultMemoryPath, _ := tm.DefaultPath()
+	flags := flag.NewFlagSet("tm "+args[0], flag.ExitOnError)
+	flags.StringVar(&memoryPath, "tm", defaultMemoryPath, "Path to the translation memory database")
+	switch args[0] {
+	case "import":
+		flags.StringVar(&provider, "provider", "google", "Provider to file units without an x-provider property under")
+	case "export":
+		flags.StringVar(&provider, "provider", "", "Only export translations of this provider")
+		flags.StringVar(&from, "from", "", "Only export translations from this language")
+		flags.StringVar(&to, "to", "", "Only export translations to this language")
+	default:
+		return fmt.Errorf("unknown tm command %q, want import or export", args[0])
+	}
+	flags.Parse(args[1:])
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: translate tm %s [flags] file.tmx", args[0])
+	}
+
+	memory, err := tm.Open(memoryPath)
+	if err != nil {
+		return err
+	}
+	defer memory.Close()
+
+	if args[0] == "import" {
+		return importTMX(memory, flags.Arg(0), provider)
+	}
+	return exportTMX(memory, flags.Arg(0), provider, from, to)
+}
+
+func importTMX(memory *tm.Memory, path, provider string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	entries, err := tm.ReadTMX(bufio.NewReader(file), provider)
+	if err != nil {
+		return err
+	}
+	if err := memory.Put(entries...); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d translations from %s\n", len(entries), path)
+	return nil
+}
+
+func exportTMX(memory *tm.Memory, path, provider, from, to string) error {
+	var entries []tm.Entry
+	err := memory.ForEach(func(entry tm.Entry) error {
+		if (provider == "" || entry.Provider == provider) &&
+			(from == "" || tm.LanguageCode(entry.From) == tm.LanguageCode(from)) &&
+			(to == "" || tm.LanguageCode(entry.To) == tm.LanguageCode(to)) {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if err := tm.WriteTMX(writer, entries); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d translations to %s\n", len(entries), path)
+	return file.Close()
+}
@@ -5,6 +5,7 @@ go 1.19
 require (
 	fyne.io/fyne/v2 v2.4.5
 	github.com/mshafiee/progressbar v1.1.0
+	go.etcd.io/bbolt v1.3.8
 	golang.org/x/text v0.14.0
 )
 
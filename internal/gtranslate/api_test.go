@@ -1,9 +1,16 @@
 package gtranslate
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/mshafiee/translate/internal/gtranslate/gtranslatetest"
+	"github.com/mshafiee/translate/internal/retry"
 )
 
 type testTable struct {
@@ -22,14 +29,19 @@ var testingTable = []testTable{
 }
 
 func TestTranslate(t *testing.T) {
+	srv := gtranslatetest.NewServer()
+	defer srv.Close()
+
+	client := &Client{BaseURL: srv.URL, HTTPClient: srv.Client()}
 	N := 5
 	var totalDur time.Duration
 	for i := 0; i < N; i++ {
 		for _, ta := range testingTable {
 			start := time.Now()
-			translated, err := translate(ta.inText, ta.langFrom, ta.langTo, true, 5, time.Second)
+			translated, err := client.translate(context.Background(), ta.inText, ta.langFrom, ta.langTo, true, retry.Policy{MaxAttempts: 5, InitialBackoff: time.Second}, "")
 			if err != nil {
 				t.Error(err.Error())
+				continue
 			}
 			if len(translated) < 2 {
 				t.Fail()
@@ -37,49 +49,134 @@ func TestTranslate(t *testing.T) {
 			dur := time.Since(start)
 			fmt.Print(".")
 			totalDur += dur
-			if translated != ta.outText {
-				t.Error("translated text is not the expected", ta.outText, " != ", translated)
+			if translated[0] != ta.outText {
+				t.Error("translated text is not the expected", ta.outText, " != ", translated[0])
 			}
 		}
 	}
 	fmt.Println("\nMean time:", time.Duration(int(totalDur)/(len(testingTable)*N)))
 }
 
-// TestGetGoogleTranslate is for testing propouse
-// func TestGetGoogleTranslate(t *testing.T) {
-// 	testText := "Some test text"
-// 	for i := 0; i < 4; i++ {
-// 		for _, ta := range testingTable {
-
-// 			r, err := getGoogleTranslate(ta.inText, ta.langFrom, ta.langTo)
-// 			if err != nil {
-// 				t.Error(err.Error())
-// 				t.Fail()
-// 			}
-// 			if r.StatusCode != http.StatusOK {
-
-// 				t.Error("[" + strconv.Itoa(r.StatusCode) + "] failed request with text: '" + testText + "'")
-// 			}
-// 			if r.Body == nil {
-// 				t.Fail()
-// 			}
-// 		}
-// 	}
-
-// }
-
-// // TestRawTranslate testing rawTranslate function
-// func TestRawTranslate(t *testing.T) {
-// 	for i := 0; i < 4; i++ {
-// 		for _, ta := range testingTable {
-// 			data, err := rawTranslate(ta.inText, ta.langFrom, ta.langTo)
-// 			if err != nil {
-// 				t.Error(err.Error())
-// 			}
-// 			if len(data) < 10 {
-// 				t.Fail()
-// 			}
-// 		}
-// 	}
-
-// }
+func TestTranslateJoinsSegmentsAndAlternatives(t *testing.T) {
+	srv := gtranslatetest.NewServer()
+	defer srv.Close()
+
+	client := &Client{BaseURL: srv.URL, HTTPClient: srv.Client()}
+	translated, err := client.Translate(context.Background(), "Hello. How are you?", TranslationParams{From: "en", To: "es"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Hola. ¿Cómo estás?", "Hola. ¿Cómo estás?", "¿Cómo está?"}
+	if len(translated) != len(want) {
+		t.Fatalf("got %q, want %q", translated, want)
+	}
+	for i := range want {
+		if translated[i] != want[i] {
+			t.Errorf("element %d: got %q, want %q", i, translated[i], want[i])
+		}
+	}
+}
+
+func TestTranslateInvalidBaseURL(t *testing.T) {
+	client := &Client{BaseURL: "http://[::1"}
+	if _, err := client.Translate(context.Background(), "Hello", TranslationParams{From: "en", To: "es"}); err == nil {
+		t.Fatal("translating with an invalid base URL succeeded")
+	}
+}
+
+func TestTranslateRetriesForbidden(t *testing.T) {
+	srv := gtranslatetest.NewServer()
+	defer srv.Close()
+	srv.FailNext(http.StatusForbidden, http.StatusForbidden)
+
+	client := &Client{BaseURL: srv.URL, HTTPClient: srv.Client()}
+	translated, err := client.Translate(context.Background(), "Hello", TranslationParams{From: "en", To: "es", Tries: 5, Delay: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if translated[0] != "Hola" {
+		t.Errorf("got %q, want Hola", translated[0])
+	}
+	if srv.Requests() != 3 {
+		t.Errorf("got %d requests, want 3", srv.Requests())
+	}
+}
+
+func TestTranslateHonorsContext(t *testing.T) {
+	srv := gtranslatetest.NewServer()
+	defer srv.Close()
+	srv.FailNext(http.StatusForbidden)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client := &Client{BaseURL: srv.URL, HTTPClient: srv.Client()}
+	_, err := client.Translate(ctx, "Hello", TranslationParams{From: "en", To: "es", Tries: 5, Delay: time.Hour})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestTranslateGivesUpAfterMaxAttempts(t *testing.T) {
+	srv := gtranslatetest.NewServer()
+	defer srv.Close()
+	srv.FailNext(http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+
+	var attempts []retry.Attempt
+	client := &Client{BaseURL: srv.URL, HTTPClient: srv.Client(), Retry: DefaultRetryPolicy()}
+	client.Retry.OnAttempt = func(a retry.Attempt) { attempts = append(attempts, a) }
+
+	_, err := client.Translate(context.Background(), "Hello", TranslationParams{From: "en", To: "es", Tries: 2, Delay: time.Millisecond})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("got %v, want a 503 StatusError", err)
+	}
+	if srv.Requests() != 2 || len(attempts) != 2 || !attempts[0].Retrying() || attempts[1].Retrying() {
+		t.Errorf("got %d requests and attempts %+v", srv.Requests(), attempts)
+	}
+}
+
+func TestTranslateDoesNotRetryBadRequest(t *testing.T) {
+	srv := gtranslatetest.NewServer()
+	defer srv.Close()
+
+	client := &Client{BaseURL: srv.URL, HTTPClient: srv.Client()}
+	_, err := client.Translate(context.Background(), "not recorded", TranslationParams{From: "en", To: "es", Tries: 5})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("got %v, want a 400 StatusError", err)
+	}
+	if srv.Requests() != 1 {
+		t.Errorf("got %d requests, want 1", srv.Requests())
+	}
+}
+
+func TestTranslateLongTextIsChunkedAndPosted(t *testing.T) {
+	srv := gtranslatetest.NewServer()
+	defer srv.Close()
+
+	first := strings.Repeat("word ", 600) + "end."
+	second := strings.Repeat("more ", 600) + "done."
+	srv.Record("en", "es", first, `[[["PRIMERO","x",null,null,10]],null,"en"]`)
+	srv.Record("en", "es", second, `[[["SEGUNDO","x",null,null,10]],null,"en"]`)
+	srv.Record("en", "es", "Hello", `[[["Hola","Hello",null,null,10]],null,"en"]`)
+
+	client := &Client{BaseURL: srv.URL, HTTPClient: srv.Client()}
+	resp, err := client.TranslateDetailed(context.Background(), first+" "+second, TranslationParams{From: "en", To: "es"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Translation(); got != "PRIMERO SEGUNDO" {
+		t.Errorf("translation %q, want the chunks joined", got)
+	}
+	if resp.SourceLanguage != "en" {
+		t.Errorf("source language %q, want en", resp.SourceLanguage)
+	}
+
+	if _, err := client.Translate(context.Background(), "Hello", TranslationParams{From: "en", To: "es"}); err != nil {
+		t.Fatal(err)
+	}
+	if methods := srv.Methods(); strings.Join(methods, ",") != "POST,POST,GET" {
+		t.Errorf("request methods %v, want long chunks posted and short text in the query", methods)
+	}
+}
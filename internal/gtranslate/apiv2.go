@@ -1,14 +1,15 @@
 package gtranslate
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
-	"time"
+	"strings"
 
+	"github.com/mshafiee/translate/internal/retry"
 	"golang.org/x/text/language"
 )
 
@@ -18,27 +19,52 @@ func init() {
 	ttk = NewTranslationToken()
 }
 
-const (
-	defaultNumberOfRetries = 2
-)
-
-func translate(text, from, to string, withVerification bool, tries int, delay time.Duration) ([]string, error) {
-	if tries == 0 {
-		tries = defaultNumberOfRetries
+func (c *Client) translate(ctx context.Context, text, from, to string, withVerification bool, policy retry.Policy, host string) ([]string, error) {
+	resp, err := c.lookup(ctx, text, from, to, withVerification, policy, host)
+	if err != nil {
+		return nil, err
 	}
+	return resp.Texts(), nil
+}
+
+// MaxLength is the longest text, in characters, Google translates in one
+// request. Longer texts are split into chunks at sentence boundaries.
+const MaxLength = 5000
 
+// maxURLLength is the longest request URL sent with GET; longer texts are
+// sent in a POST body instead.
+const maxURLLength = 2000
+
+func (c *Client) lookup(ctx context.Context, text, from, to string, withVerification bool, policy retry.Policy, host string) (*Response, error) {
 	if withVerification {
 		if _, err := language.Parse(from); err != nil && from != "auto" {
-			log.Println("[WARNING], '" + from + "' is a invalid language, switching to 'auto'")
+			c.logger().Println("[WARNING], '" + from + "' is a invalid language, switching to 'auto'")
 			from = "auto"
 		}
 		if _, err := language.Parse(to); err != nil {
-			log.Println("[WARNING], '" + to + "' is a invalid language, switching to 'en'")
+			c.logger().Println("[WARNING], '" + to + "' is a invalid language, switching to 'en'")
 			to = "en"
 		}
 	}
 
-	urll := fmt.Sprintf("https://translate.%s/translate_a/single", GoogleHost)
+	chunks := SplitIntoChunks(text, MaxLength)
+	if len(chunks) == 1 {
+		return c.request(ctx, text, from, to, policy, host)
+	}
+
+	responses := make([]*Response, len(chunks))
+	for i, chunk := range chunks {
+		resp, err := c.request(ctx, chunk, from, to, policy, host)
+		if err != nil {
+			return nil, err
+		}
+		responses[i] = resp
+	}
+	return merge(chunks, responses), nil
+}
+
+func (c *Client) request(ctx context.Context, text, from, to string, policy retry.Policy, host string) (*Response, error) {
+	urll := c.baseURL(host) + "/translate_a/single"
 
 	//token, err := ttk.Get(text)
 	//if err != nil {
@@ -56,12 +82,11 @@ func translate(text, from, to string, withVerification bool, tries int, delay ti
 		"ssel":   "0",
 		"tsel":   "0",
 		"kc":     "7",
-		"q":      text,
 	}
 
 	u, err := url.Parse(urll)
 	if err != nil {
-		return nil, nil
+		return nil, fmt.Errorf("gtranslate: invalid base URL: %w", err)
 	}
 
 	parameters := url.Values{}
@@ -76,77 +101,107 @@ func translate(text, from, to string, withVerification bool, tries int, delay ti
 	//parameters.Add("tk", token)
 	u.RawQuery = parameters.Encode()
 
-	var r *http.Response
-
-	for tries > 0 {
-		r, err = http.Get(u.String())
-		log.Println(u.String())
-		if err != nil {
-			if err == http.ErrHandlerTimeout {
-				return nil, errBadNetwork
-			}
-			return nil, err
-		}
-
-		if r.StatusCode == http.StatusOK {
-			break
+	// Short texts go in the query string; long ones in a form body, which
+	// has no length limit.
+	q := url.Values{"q": {text}}.Encode()
+	var req *http.Request
+	if len(u.String())+1+len(q) <= maxURLLength {
+		u.RawQuery += "&" + q
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(q))
+		if req != nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=UTF-8")
 		}
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		if r.StatusCode == http.StatusForbidden {
-			tries--
-			time.Sleep(delay)
+	r, err := policy.Do(c.httpClient(), req)
+	if err != nil {
+		if err == http.ErrHandlerTimeout {
+			return nil, errBadNetwork
 		}
+		return nil, err
 	}
+	defer r.Body.Close()
 
 	raw, err := io.ReadAll(r.Body)
 	if err != nil {
 		return nil, err
 	}
 
-	var resp []interface{}
+	if r.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: r.StatusCode, Body: string(raw)}
+	}
 
-	err = json.Unmarshal([]byte(raw), &resp)
+	resp := new(Response)
+	err = json.Unmarshal(raw, resp)
 	if err != nil {
 		return nil, err
 	}
 
-	responseTextArr := make([]string, 1)
-	if resp[0] != nil {
+	return resp, nil
+}
 
+// merge reassembles the responses to the chunks of a text into the
+// response to the whole text. Language detection comes from the first
+// chunk; word level slots, such as the dictionary, only apply to single
+// words and are left empty.
+func merge(chunks []string, responses []*Response) *Response {
+	merged := &Response{
+		SourceLanguage:    responses[0].SourceLanguage,
+		Confidence:        responses[0].Confidence,
+		DetectedLanguages: responses[0].DetectedLanguages,
 	}
-	a, ok := resp[0].([]interface{})
-	if ok {
-		for _, obj := range a {
-			if len(obj.([]interface{})) == 0 {
-				break
-			}
 
-			t, ok := obj.([]interface{})[0].(string)
-			if ok {
-				responseTextArr[0] += t
-			}
+	var translit, original, corrections []string
+	corrected := false
+	for i, resp := range responses {
+		last := i == len(responses)-1
+
+		sentences := append([]Sentence(nil), resp.Sentences...)
+		if n := len(sentences); n > 0 && !last {
+			sentences[n-1].Translated = withSpace(sentences[n-1].Translated)
 		}
-	}
-	obj1, ok := resp[5].([]interface{})
-	if ok {
-		for _, obj2 := range obj1 {
-			if len(obj2.([]interface{})) == 0 {
-				break
-			}
-			obj3, ok := obj2.([]interface{})[2].([]interface{})
-			if ok {
-				for i, obj4 := range obj3 {
-					obj5, ok := obj4.([]interface{})[0].(string)
-					if ok {
-						if len(responseTextArr) < i+2 {
-							responseTextArr = append(responseTextArr, "")
-						}
-						responseTextArr[i+1] += obj5
-					}
-				}
+		merged.Sentences = append(merged.Sentences, sentences...)
+
+		alternatives := append([]Alternative(nil), resp.Alternatives...)
+		if n := len(alternatives); n > 0 && !last {
+			translations := append([]AlternativeTranslation(nil), alternatives[n-1].Translations...)
+			for j := range translations {
+				translations[j].Text = withSpace(translations[j].Text)
 			}
+			alternatives[n-1].Translations = translations
+		}
+		merged.Alternatives = append(merged.Alternatives, alternatives...)
+
+		translit = append(translit, resp.Transliteration.Translated)
+		original = append(original, resp.Transliteration.Original)
+
+		if resp.SpellingCorrection != "" {
+			corrected = true
+			corrections = append(corrections, resp.SpellingCorrection)
+		} else {
+			corrections = append(corrections, chunks[i])
 		}
 	}
 
-	return responseTextArr, nil
+	merged.Transliteration = Transliteration{
+		Translated: strings.TrimSpace(strings.Join(translit, " ")),
+		Original:   strings.TrimSpace(strings.Join(original, " ")),
+	}
+	if corrected {
+		merged.SpellingCorrection = strings.Join(corrections, " ")
+	}
+	return merged
+}
+
+// withSpace appends a space separating s from the text of the next chunk.
+func withSpace(s string) string {
+	if s == "" || strings.HasSuffix(s, " ") {
+		return s
+	}
+	return s + " "
 }
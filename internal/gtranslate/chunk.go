@@ -0,0 +1,65 @@
+package gtranslate
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// SplitIntoChunks splits text into chunks of at most max characters, packing
+// whole sentences, as found by SplitIntoSentences, into each chunk. A
+// sentence longer than max is split between words, and a word longer than
+// max between characters. Joining the chunks with a space gives back text
+// with its white space collapsed.
+func SplitIntoChunks(text string, max int) []string {
+	if max <= 0 || utf8.RuneCountInString(text) <= max {
+		return []string{text}
+	}
+
+	var chunks []string
+	var chunk strings.Builder
+	size := 0
+	add := func(piece string) {
+		n := utf8.RuneCountInString(piece)
+		if size > 0 && size+1+n > max {
+			chunks = append(chunks, chunk.String())
+			chunk.Reset()
+			size = 0
+		}
+		if size > 0 {
+			chunk.WriteByte(' ')
+			size++
+		}
+		chunk.WriteString(piece)
+		size += n
+	}
+
+	for _, sentence := range SplitIntoSentences(text) {
+		if utf8.RuneCountInString(sentence) <= max {
+			add(sentence)
+			continue
+		}
+		for _, word := range strings.Fields(sentence) {
+			for utf8.RuneCountInString(word) > max {
+				cut := runeOffset(word, max)
+				add(word[:cut])
+				word = word[cut:]
+			}
+			add(word)
+		}
+	}
+	if size > 0 {
+		chunks = append(chunks, chunk.String())
+	}
+	return chunks
+}
+
+// runeOffset returns the byte offset of the n-th character of s.
+func runeOffset(s string, n int) int {
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return len(s)
+}
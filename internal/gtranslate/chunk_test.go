@@ -0,0 +1,44 @@
+package gtranslate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitIntoChunks(t *testing.T) {
+	text := "One two three. Four five six! Seven eight nine ten eleven twelve thirteen. End."
+	chunks := SplitIntoChunks(text, 30)
+
+	want := []string{
+		"One two three. Four five six!",
+		"Seven eight nine ten eleven",
+		"twelve thirteen. End.",
+	}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Errorf("got %q, want %q", chunks, want)
+	}
+	if strings.Join(chunks, " ") != text {
+		t.Errorf("joined chunks differ from the text")
+	}
+}
+
+func TestSplitIntoChunksLongWord(t *testing.T) {
+	word := strings.Repeat("ب", 25)
+	chunks := SplitIntoChunks(word, 10)
+	if len(chunks) != 3 || strings.Join(chunks, "") != word {
+		t.Fatalf("got %q", chunks)
+	}
+	for _, chunk := range chunks {
+		if utf8.RuneCountInString(chunk) > 10 {
+			t.Errorf("chunk %q is longer than 10 characters", chunk)
+		}
+	}
+}
+
+func TestSplitIntoChunksShortText(t *testing.T) {
+	if chunks := SplitIntoChunks("  short  text ", 100); len(chunks) != 1 || chunks[0] != "  short  text " {
+		t.Errorf("short text changed: %q", chunks)
+	}
+}
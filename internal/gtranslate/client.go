@@ -0,0 +1,165 @@
+package gtranslate
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/mshafiee/translate/internal/retry"
+)
+
+// DefaultRetryPolicy returns the retry policy of clients that do not set
+// one. Besides the usual throttling and server errors it retries 403, which
+// Google answers when it suspects automated traffic.
+func DefaultRetryPolicy() retry.Policy {
+	policy := retry.DefaultPolicy()
+	policy.RetryableStatus = append([]int{http.StatusForbidden}, policy.RetryableStatus...)
+	return policy
+}
+
+// Client translates texts through the Google translate_a/single endpoint.
+// Its fields must not be modified once the client is in use; a Client is
+// safe for concurrent use.
+type Client struct {
+	// Host is the Google domain, e.g. google.com or google.cn.
+	Host string
+	// BaseURL overrides the endpoint derived from Host, e.g. the URL of a
+	// gtranslatetest.Server. It takes precedence over any host.
+	BaseURL string
+	// HTTPClient sends the requests; http.DefaultClient when nil.
+	HTTPClient *http.Client
+	// Retry is the retry policy; DefaultRetryPolicy() when MaxAttempts is 0.
+	// TranslationParams.Tries and Delay override its attempts and initial
+	// backoff.
+	Retry retry.Policy
+	// Logger receives diagnostic messages; log.Default() when nil.
+	Logger *log.Logger
+}
+
+// NewClient returns a Client for host with the default retry policy.
+func NewClient(host string) *Client {
+	return &Client{
+		Host:  host,
+		Retry: DefaultRetryPolicy(),
+	}
+}
+
+// Translate translates text according to params. The request is aborted
+// when ctx is cancelled or its deadline expires. The first element of the
+// result is the translation, the following ones are alternatives.
+func (c *Client) Translate(ctx context.Context, text string, params TranslationParams) ([]string, error) {
+	return c.translate(ctx, text, params.From, params.To, true, c.retryPolicy(params), params.GoogleHost)
+}
+
+// TranslateDetailed translates text according to params and returns the
+// whole decoded response, including dictionary entries, definitions,
+// examples and language detection.
+func (c *Client) TranslateDetailed(ctx context.Context, text string, params TranslationParams) (*Response, error) {
+	return c.lookup(ctx, text, params.From, params.To, true, c.retryPolicy(params), params.GoogleHost)
+}
+
+// Vocabulary translates every meaningful word of text and formats each as
+// "word: meaning,meaning.".
+func (c *Client) Vocabulary(ctx context.Context, text string, params TranslationParams) ([]string, error) {
+	var vocabularyMeaning []string
+
+	policy := c.retryPolicy(params)
+	words := SplitIntoWordsFile(text)
+
+	for _, w := range words {
+		translated, err := c.translate(ctx, w, params.From, params.To, true, policy, params.GoogleHost)
+		if err != nil {
+			return nil, err
+		}
+
+		vocab := fmt.Sprintf("%s: ", w)
+		for i, t := range translated {
+			if i != len(translated)-1 {
+				vocab += fmt.Sprintf("%s,", t)
+			} else {
+				vocab += fmt.Sprintf("%s.", t)
+			}
+		}
+		vocabularyMeaning = append(vocabularyMeaning, vocab)
+	}
+
+	return vocabularyMeaning, nil
+}
+
+// Sentence translates every sentence of text and formats each as
+// "sentence: translation alternatives...".
+func (c *Client) Sentence(ctx context.Context, text string, params TranslationParams) ([]string, error) {
+	var sentenceMeaning []string
+
+	policy := c.retryPolicy(params)
+	sentences := SplitIntoSentences(text)
+
+	for _, s := range sentences {
+		translated, err := c.translate(ctx, s, params.From, params.To, true, policy, params.GoogleHost)
+		if err != nil {
+			return nil, err
+		}
+
+		// Create a map to keep track of which strings we have seen
+		seen := make(map[string]bool)
+
+		sentence := fmt.Sprintf("%s: ", s)
+		for _, t := range translated {
+			if !seen[t] {
+				sentence += fmt.Sprintf("%s ", t)
+				seen[t] = true
+			}
+		}
+		sentenceMeaning = append(sentenceMeaning, sentence)
+	}
+
+	return sentenceMeaning, nil
+}
+
+func (c *Client) retryPolicy(params TranslationParams) retry.Policy {
+	policy := c.Retry
+	if policy.MaxAttempts == 0 {
+		policy = DefaultRetryPolicy()
+		policy.OnAttempt = c.Retry.OnAttempt
+		policy.Throttle = c.Retry.Throttle
+	}
+	if params.Tries != 0 {
+		policy.MaxAttempts = params.Tries
+	}
+	if params.Delay != 0 {
+		policy.InitialBackoff = params.Delay
+		if policy.MaxBackoff < params.Delay {
+			policy.MaxBackoff = params.Delay
+		}
+	}
+	return policy
+}
+
+func (c *Client) baseURL(hostOverride string) string {
+	if c.BaseURL != "" {
+		return strings.TrimRight(c.BaseURL, "/")
+	}
+	host := GoogleHost
+	if hostOverride != "" {
+		host = hostOverride
+	} else if c.Host != "" {
+		host = c.Host
+	}
+	return fmt.Sprintf("https://translate.%s", host)
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c *Client) logger() *log.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return log.Default()
+}
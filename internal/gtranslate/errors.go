@@ -1,6 +1,20 @@
 package gtranslate
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var errBadNetwork = errors.New("bad network, please check your internet connection")
 var errBadRequest = errors.New("bad request, request on google translate api isn't working")
+
+// StatusError is returned when google translate keeps answering with a
+// non-200 status after all retries.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s (status %d)", errBadRequest, e.StatusCode)
+}
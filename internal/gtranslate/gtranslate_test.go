@@ -1,14 +1,21 @@
 package gtranslate
 
 import (
+	"context"
 	"testing"
 	"time"
+
+	"github.com/mshafiee/translate/internal/gtranslate/gtranslatetest"
 )
 
 func TestTranslateWithFromTo(t *testing.T) {
+	srv := gtranslatetest.NewServer()
+	defer srv.Close()
+
+	client := &Client{BaseURL: srv.URL, HTTPClient: srv.Client()}
 	for i := 0; i < 4; i++ {
 		for _, ta := range testingTable {
-			resp, err := TranslateWithParams(ta.inText, TranslationParams{
+			resp, err := client.Translate(context.Background(), ta.inText, TranslationParams{
 				From:       ta.langFrom,
 				To:         ta.langTo,
 				Tries:      5,
@@ -18,10 +25,28 @@ func TestTranslateWithFromTo(t *testing.T) {
 			if err != nil {
 				t.Error(err, err.Error())
 				t.Fail()
+				continue
 			}
-			if resp != ta.outText {
-				t.Error("translated text is not the expected", ta.outText, " != ", resp)
+			if resp[0] != ta.outText {
+				t.Error("translated text is not the expected", ta.outText, " != ", resp[0])
 			}
 		}
 	}
 }
+
+func TestSentenceWithParams(t *testing.T) {
+	srv := gtranslatetest.NewServer()
+	defer srv.Close()
+
+	srv.Record("en", "es", "Hello,", `[[["Hola,","Hello,",null,null,10]],null,"en",null,null,[["Hello,",null,[["Hola,",1000,true,false,[10]]],[[0,6]],"Hello,",0,0]],1,[],[["en"],null,[1],["en"]]]`)
+
+	client := &Client{BaseURL: srv.URL, HTTPClient: srv.Client()}
+	sentences, err := client.Sentence(context.Background(), "Hello, World", TranslationParams{From: "en", To: "es"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Hello,: Hola, ", "World: Mundo El mundo "}
+	if len(sentences) != len(want) || sentences[0] != want[0] || sentences[1] != want[1] {
+		t.Errorf("got %q, want %q", sentences, want)
+	}
+}
@@ -0,0 +1,110 @@
+// Package gtranslatetest provides a fake Google translate_a/single endpoint
+// replaying recorded responses, so code using gtranslate can be tested
+// without network access.
+package gtranslatetest
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+//go:embed testdata/recordings.json
+var recordings []byte
+
+// Server is an httptest.Server answering translate_a/single requests. Use
+// its URL as gtranslate.Client.BaseURL.
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	recordings map[string]json.RawMessage
+	failures   []int
+	requests   int
+	methods    []string
+}
+
+// NewServer starts a Server loaded with the bundled recordings. The caller
+// must Close it.
+func NewServer() *Server {
+	s := &Server{recordings: make(map[string]json.RawMessage)}
+	if err := json.Unmarshal(recordings, &s.recordings); err != nil {
+		panic("gtranslatetest: invalid bundled recordings: " + err.Error())
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Record makes the server answer the translation of text from one language
+// to another with response, a raw translate_a/single JSON document.
+func (s *Server) Record(from, to, text, response string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recordings[key(from, to, text)] = json.RawMessage(response)
+}
+
+// FailNext makes the next requests fail with the given status codes, one per
+// request, before recordings are served again.
+func (s *Server) FailNext(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, statuses...)
+}
+
+// Requests returns the number of requests received so far.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// Methods returns the HTTP methods of the requests received so far, in
+// order.
+func (s *Server) Methods() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.methods...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	s.methods = append(s.methods, r.Method)
+	if r.URL.Path != "/translate_a/single" {
+		http.NotFound(w, r)
+		return
+	}
+	if len(s.failures) > 0 {
+		status := s.failures[0]
+		s.failures = s.failures[1:]
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.Form.Get("client") != "gtx" {
+		http.Error(w, "missing client=gtx", http.StatusBadRequest)
+		return
+	}
+
+	response, ok := s.recordings[key(r.Form.Get("sl"), r.Form.Get("tl"), r.Form.Get("q"))]
+	if !ok {
+		http.Error(w, fmt.Sprintf("no recording for sl=%s tl=%s q=%q", r.Form.Get("sl"), r.Form.Get("tl"), r.Form.Get("q")), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(response)
+}
+
+func key(from, to, text string) string {
+	return from + "|" + to + "|" + text
+}
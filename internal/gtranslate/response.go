@@ -0,0 +1,298 @@
+package gtranslate
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var errBadResponse = errors.New("bad response, google translate answered with an unexpected document")
+
+// Response is the decoded answer of the translate_a/single endpoint. The
+// endpoint returns a positional JSON array whose slots are filled according
+// to the requested dt values; slots that are missing or have an unexpected
+// shape are left empty instead of failing the decoding.
+type Response struct {
+	// Sentences holds the translated segments (dt=t).
+	Sentences []Sentence
+	// Transliteration of the whole text (dt=rm).
+	Transliteration Transliteration
+	// Dictionary holds per part-of-speech translations of a single word
+	// (dt=bd).
+	Dictionary []DictionaryEntry
+	// SourceLanguage is the language the text was translated from.
+	SourceLanguage string
+	// Alternatives holds alternative translations per source phrase (dt=at).
+	Alternatives []Alternative
+	// Confidence of the source language detection.
+	Confidence float64
+	// SpellingCorrection is the corrected source text, empty when the text
+	// was spelled correctly (dt=qca).
+	SpellingCorrection string
+	// DetectedLanguages lists the candidate source languages (dt=ld).
+	DetectedLanguages []DetectedLanguage
+	// Synonyms of a single word grouped by part of speech (dt=ss).
+	Synonyms []SynonymSet
+	// Definitions of a single word grouped by part of speech (dt=md).
+	Definitions []Definition
+	// Examples are usage examples of a single word (dt=ex).
+	Examples []string
+	// RelatedWords are "see also" suggestions (dt=rw).
+	RelatedWords []string
+}
+
+// Sentence is a translated segment and the original text it came from.
+type Sentence struct {
+	Translated string
+	Original   string
+}
+
+// Transliteration is the romanization of the translated and original text.
+type Transliteration struct {
+	Translated string
+	Original   string
+}
+
+// DictionaryEntry lists the translations of a word for one part of speech.
+type DictionaryEntry struct {
+	PartOfSpeech string
+	Terms        []string
+	Entries      []DictionaryTerm
+	BaseForm     string
+}
+
+// DictionaryTerm is a translation with its reverse translations.
+type DictionaryTerm struct {
+	Word                string
+	ReverseTranslations []string
+	Score               float64
+}
+
+// Alternative lists alternative translations of a source phrase.
+type Alternative struct {
+	Source       string
+	Translations []AlternativeTranslation
+}
+
+// AlternativeTranslation is one candidate translation of a phrase.
+type AlternativeTranslation struct {
+	Text  string
+	Score float64
+}
+
+// DetectedLanguage is a candidate source language.
+type DetectedLanguage struct {
+	Language   string
+	Confidence float64
+}
+
+// SynonymSet lists synonym groups for one part of speech.
+type SynonymSet struct {
+	PartOfSpeech string
+	Groups       [][]string
+}
+
+// Definition lists the glosses of a word for one part of speech.
+type Definition struct {
+	PartOfSpeech string
+	Entries      []DefinitionEntry
+}
+
+// DefinitionEntry is a gloss with an optional usage example.
+type DefinitionEntry struct {
+	Gloss   string
+	Example string
+}
+
+// Translation returns the full translated text.
+func (r *Response) Translation() string {
+	var b strings.Builder
+	for _, s := range r.Sentences {
+		b.WriteString(s.Translated)
+	}
+	return b.String()
+}
+
+// Texts returns the translation followed by the alternative translations
+// of the whole text, built by joining the n-th alternative of every phrase
+// with the white space separating the phrases in the source text.
+func (r *Response) Texts() []string {
+	texts := []string{r.Translation()}
+
+	var original strings.Builder
+	for _, s := range r.Sentences {
+		original.WriteString(s.Original)
+	}
+	source := original.String()
+
+	for _, alt := range r.Alternatives {
+		space := ""
+		if i := strings.Index(source, alt.Source); i >= 0 {
+			if strings.TrimSpace(source[:i]) == "" {
+				space = source[:i]
+			}
+			source = source[i+len(alt.Source):]
+		}
+
+		for i, t := range alt.Translations {
+			if len(texts) < i+2 {
+				texts = append(texts, "")
+			}
+			if texts[i+1] != "" {
+				texts[i+1] += space
+			}
+			texts[i+1] += t.Text
+		}
+	}
+	return texts
+}
+
+// UnmarshalJSON decodes the positional array returned by the endpoint.
+func (r *Response) UnmarshalJSON(data []byte) error {
+	var slots []json.RawMessage
+	if err := json.Unmarshal(data, &slots); err != nil {
+		return errBadResponse
+	}
+
+	*r = Response{}
+	for _, raw := range array(index(slots, 0)) {
+		item := array(raw)
+		// The transliteration is appended as an entry with empty texts.
+		if str(index(item, 0)) == "" && str(index(item, 1)) == "" {
+			if t, o := str(index(item, 2)), str(index(item, 3)); t != "" || o != "" {
+				r.Transliteration = Transliteration{Translated: t, Original: o}
+			}
+			continue
+		}
+		r.Sentences = append(r.Sentences, Sentence{Translated: str(index(item, 0)), Original: str(index(item, 1))})
+	}
+
+	for _, raw := range array(index(slots, 1)) {
+		item := array(raw)
+		entry := DictionaryEntry{
+			PartOfSpeech: str(index(item, 0)),
+			Terms:        strs(index(item, 1)),
+			BaseForm:     str(index(item, 3)),
+		}
+		for _, rawTerm := range array(index(item, 2)) {
+			term := array(rawTerm)
+			entry.Entries = append(entry.Entries, DictionaryTerm{
+				Word:                str(index(term, 0)),
+				ReverseTranslations: strs(index(term, 1)),
+				Score:               num(index(term, 3)),
+			})
+		}
+		r.Dictionary = append(r.Dictionary, entry)
+	}
+
+	r.SourceLanguage = str(index(slots, 2))
+
+	for _, raw := range array(index(slots, 5)) {
+		item := array(raw)
+		alt := Alternative{Source: str(index(item, 0))}
+		for _, rawTranslation := range array(index(item, 2)) {
+			translation := array(rawTranslation)
+			alt.Translations = append(alt.Translations, AlternativeTranslation{
+				Text:  str(index(translation, 0)),
+				Score: num(index(translation, 1)),
+			})
+		}
+		r.Alternatives = append(r.Alternatives, alt)
+	}
+
+	r.Confidence = num(index(slots, 6))
+	r.SpellingCorrection = str(index(array(index(slots, 7)), 1))
+
+	detection := array(index(slots, 8))
+	confidences := array(index(detection, 2))
+	for i, language := range strs(index(detection, 0)) {
+		r.DetectedLanguages = append(r.DetectedLanguages, DetectedLanguage{
+			Language:   language,
+			Confidence: num(index(confidences, i)),
+		})
+	}
+
+	for _, raw := range array(index(slots, 11)) {
+		item := array(raw)
+		set := SynonymSet{PartOfSpeech: str(index(item, 0))}
+		for _, group := range array(index(item, 1)) {
+			set.Groups = append(set.Groups, strs(index(array(group), 0)))
+		}
+		r.Synonyms = append(r.Synonyms, set)
+	}
+
+	for _, raw := range array(index(slots, 12)) {
+		item := array(raw)
+		definition := Definition{PartOfSpeech: str(index(item, 0))}
+		for _, rawEntry := range array(index(item, 1)) {
+			entry := array(rawEntry)
+			definition.Entries = append(definition.Entries, DefinitionEntry{
+				Gloss:   str(index(entry, 0)),
+				Example: str(index(entry, 2)),
+			})
+		}
+		r.Definitions = append(r.Definitions, definition)
+	}
+
+	for _, raw := range array(index(array(index(slots, 13)), 0)) {
+		if example := stripTags(str(index(array(raw), 0))); example != "" {
+			r.Examples = append(r.Examples, example)
+		}
+	}
+
+	for _, raw := range array(index(slots, 14)) {
+		r.RelatedWords = append(r.RelatedWords, strs(raw)...)
+	}
+
+	return nil
+}
+
+// index returns the i-th element of a or nil when out of range.
+func index(a []json.RawMessage, i int) json.RawMessage {
+	if i < 0 || i >= len(a) {
+		return nil
+	}
+	return a[i]
+}
+
+// array decodes raw as an array, returning nil for any other JSON value.
+func array(raw json.RawMessage) []json.RawMessage {
+	var a []json.RawMessage
+	if json.Unmarshal(raw, &a) != nil {
+		return nil
+	}
+	return a
+}
+
+// str decodes raw as a string, returning "" for any other JSON value.
+func str(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) != nil {
+		return ""
+	}
+	return s
+}
+
+// strs decodes the string elements of an array, skipping other values.
+func strs(raw json.RawMessage) []string {
+	var result []string
+	for _, item := range array(raw) {
+		if s := str(item); s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// num decodes raw as a number, returning 0 for any other JSON value.
+func num(raw json.RawMessage) float64 {
+	var f float64
+	if json.Unmarshal(raw, &f) != nil {
+		return 0
+	}
+	return f
+}
+
+func stripTags(s string) string {
+	return strings.NewReplacer("<b>", "", "</b>", "", "<i>", "", "</i>", "").Replace(s)
+}
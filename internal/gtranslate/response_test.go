@@ -0,0 +1,103 @@
+package gtranslate
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/mshafiee/translate/internal/gtranslate/gtranslatetest"
+)
+
+func TestTranslateDetailed(t *testing.T) {
+	srv := gtranslatetest.NewServer()
+	defer srv.Close()
+
+	client := &Client{BaseURL: srv.URL, HTTPClient: srv.Client()}
+	resp, err := client.TranslateDetailed(context.Background(), "hello", TranslationParams{From: "en", To: "es"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := resp.Translation(); got != "hola" {
+		t.Errorf("translation: got %q, want hola", got)
+	}
+	if resp.SourceLanguage != "en" || resp.Confidence != 1 {
+		t.Errorf("source: got %q (%v)", resp.SourceLanguage, resp.Confidence)
+	}
+	if resp.Transliteration.Original != "həˈlō" {
+		t.Errorf("transliteration: got %+v", resp.Transliteration)
+	}
+	if len(resp.Dictionary) != 1 || resp.Dictionary[0].PartOfSpeech != "interjection" ||
+		!reflect.DeepEqual(resp.Dictionary[0].Entries[1].ReverseTranslations, []string{"hello", "hullo"}) {
+		t.Errorf("dictionary: got %+v", resp.Dictionary)
+	}
+	if got := resp.Texts(); !reflect.DeepEqual(got, []string{"hola", "hola", "Hola"}) {
+		t.Errorf("texts: got %q", got)
+	}
+	if len(resp.Synonyms) != 1 || !reflect.DeepEqual(resp.Synonyms[0].Groups[0], []string{"hi", "howdy", "hey", "hiya"}) {
+		t.Errorf("synonyms: got %+v", resp.Synonyms)
+	}
+	if len(resp.Definitions) != 1 || resp.Definitions[0].Entries[0].Example != "hello there, Katie!" {
+		t.Errorf("definitions: got %+v", resp.Definitions)
+	}
+	if !reflect.DeepEqual(resp.Examples, []string{`they were about to leave when a cheery voice called out "hello!"`}) {
+		t.Errorf("examples: got %q", resp.Examples)
+	}
+	if !reflect.DeepEqual(resp.RelatedWords, []string{"hello there"}) {
+		t.Errorf("related words: got %q", resp.RelatedWords)
+	}
+	if !reflect.DeepEqual(resp.DetectedLanguages, []DetectedLanguage{{Language: "en", Confidence: 1}}) {
+		t.Errorf("detected languages: got %+v", resp.DetectedLanguages)
+	}
+}
+
+func TestTranslateDetailedSpellingCorrection(t *testing.T) {
+	srv := gtranslatetest.NewServer()
+	defer srv.Close()
+
+	client := &Client{BaseURL: srv.URL, HTTPClient: srv.Client()}
+	resp, err := client.TranslateDetailed(context.Background(), "helo wrld", TranslationParams{From: "en", To: "es"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.SpellingCorrection != "hello world" {
+		t.Errorf("spelling correction: got %q", resp.SpellingCorrection)
+	}
+	if len(resp.DetectedLanguages) != 2 || resp.DetectedLanguages[1].Language != "it" || resp.DetectedLanguages[1].Confidence != 0.13 {
+		t.Errorf("detected languages: got %+v", resp.DetectedLanguages)
+	}
+}
+
+func TestResponseUnexpectedShapes(t *testing.T) {
+	for _, doc := range []string{
+		`[]`,
+		`[null]`,
+		`[[["hola"]], 3, {"a": 1}]`,
+		`[[[]], null, "en", null, null, [[], "x", [1, [2]]]]`,
+		`[[[1, 2]], [["noun", "x", [null, ["a"]]]], null, null, null, [["a", null, ["b", [3]]]], "high", 5, ["en"]]`,
+	} {
+		var resp Response
+		if err := json.Unmarshal([]byte(doc), &resp); err != nil {
+			t.Errorf("%s: %v", doc, err)
+		}
+	}
+
+	var resp Response
+	if err := json.Unmarshal([]byte(`{"sentences": []}`), &resp); err == nil {
+		t.Error("expected an error for a non-array document")
+	}
+}
+
+func TestTextsKeepSourceSpacing(t *testing.T) {
+	resp := Response{
+		Sentences: []Sentence{{Translated: "Hello. ", Original: "你好。"}, {Translated: "Bye.", Original: "再见。"}},
+		Alternatives: []Alternative{
+			{Source: "你好。", Translations: []AlternativeTranslation{{Text: "Hello."}, {Text: "Hi."}}},
+			{Source: "再见。", Translations: []AlternativeTranslation{{Text: "Bye."}, {Text: "Goodbye."}}},
+		},
+	}
+	if got, want := resp.Texts(), []string{"Hello. Bye.", "Hello.Bye.", "Hi.Goodbye."}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
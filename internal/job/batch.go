@@ -0,0 +1,78 @@
+package job
+
+import (
+	"unicode/utf8"
+
+	"github.com/mshafiee/translate/internal/output"
+)
+
+const (
+	// DefaultBatchLines is the default number of lines sent in one request.
+	DefaultBatchLines = 10
+	// DefaultBatchChars is the default number of characters sent in one
+	// request.
+	DefaultBatchChars = 2000
+)
+
+// Batch is a run of consecutive lines of a paragraph translated in one
+// request, with the context surrounding the run.
+type Batch struct {
+	Records []output.Record
+	Before  []string
+	After   []string
+}
+
+// Sources returns the text of the lines of the batch.
+func (b Batch) Sources() []string {
+	sources := make([]string, len(b.Records))
+	for i, rec := range b.Records {
+		sources[i] = rec.Source
+	}
+	return sources
+}
+
+// Batcher groups consecutive lines into batches of at most MaxLines lines
+// and MaxChars characters. A line longer than MaxChars makes a batch of its
+// own.
+type Batcher struct {
+	MaxLines int
+	MaxChars int
+
+	batch Batch
+	chars int
+}
+
+// NewBatcher returns a Batcher with the given limits.
+func NewBatcher(maxLines, maxChars int) *Batcher {
+	return &Batcher{MaxLines: maxLines, MaxChars: maxChars}
+}
+
+// Fits reports whether rec can join the pending batch.
+func (b *Batcher) Fits(rec output.Record) bool {
+	return len(b.batch.Records) == 0 || b.chars+utf8.RuneCountInString(rec.Source) <= b.MaxChars
+}
+
+// Add appends rec, preceded by before and followed by after, to the pending
+// batch.
+func (b *Batcher) Add(rec output.Record, before, after []string) {
+	if len(b.batch.Records) == 0 {
+		b.batch.Before = before
+	}
+	b.batch.Records = append(b.batch.Records, rec)
+	b.batch.After = after
+	b.chars += utf8.RuneCountInString(rec.Source)
+}
+
+// Full reports whether the pending batch reached MaxLines.
+func (b *Batcher) Full() bool {
+	return len(b.batch.Records) >= b.MaxLines
+}
+
+// Flush returns the pending batch and starts a new one. The returned batch
+// has no records when nothing was pending.
+func (b *Batcher) Flush() Batch {
+	batch := b.batch
+	b.batch = Batch{}
+	b.chars = 0
+	return batch
+}
@@ -0,0 +1,45 @@
+package job
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mshafiee/translate/internal/output"
+)
+
+func TestBatcher(t *testing.T) {
+	b := NewBatcher(3, 10)
+	var batches []Batch
+	add := func(line int, source string) {
+		rec := output.Record{Line: line, Source: source}
+		if !b.Fits(rec) {
+			batches = append(batches, b.Flush())
+		}
+		b.Add(rec, []string{"before " + source}, []string{"after " + source})
+		if b.Full() {
+			batches = append(batches, b.Flush())
+		}
+	}
+
+	add(1, "a")
+	add(2, "b")
+	add(3, "c")
+	add(4, "dddd")
+	add(5, "eeeeeeeeee")
+	add(6, "f")
+	batches = append(batches, b.Flush())
+
+	var got []string
+	for _, batch := range batches {
+		got = append(got, strings.Join(batch.Sources(), "+"))
+	}
+	if want := "a+b+c|dddd|eeeeeeeeee|f"; strings.Join(got, "|") != want {
+		t.Fatalf("batches %q, want %q", got, want)
+	}
+	if first := batches[0]; first.Before[0] != "before a" || first.After[0] != "after c" {
+		t.Errorf("first batch has context %q and %q", first.Before, first.After)
+	}
+	if len(b.Flush().Records) != 0 {
+		t.Error("flushing twice returned records")
+	}
+}
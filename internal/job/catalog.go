@@ -0,0 +1,349 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/mshafiee/translate/internal/output"
+	"github.com/mshafiee/translate/internal/po"
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+// catalogUnit is a string of a catalog entry to translate: its msgstr, or
+// one msgstr[n] of a plural entry. Leading and trailing newlines are kept
+// out of the text sent and added back to the translation.
+type catalogUnit struct {
+	entry     int
+	slot      int
+	overwrite bool
+	prefix    string
+	suffix    string
+
+	// The number sample sent in place of the placeholder of a plural
+	// entry, turned back into the placeholder in the translation.
+	sample      string
+	placeholder string
+}
+
+// countPlaceholder matches printf integer conversions, the usual plural
+// count placeholders, and escaped percent signs.
+var countPlaceholder = regexp.MustCompile(`%%|%(?:\d+\$|\(\w+\))?[-+ #0']*\d*(?:hh|h|ll|l|j|z|t|q)?[diu]`)
+
+// NeedsTranslation reports whether entry lacks a translation, or is fuzzy
+// when fuzzy is set. A plural entry lacks one if any of its nplurals
+// translations is missing or empty. The header and obsolete entries never
+// need one.
+func NeedsTranslation(entry po.PoEntry, nplurals int, fuzzy bool) bool {
+	if entry.IsHeader() || entry.Obsolete {
+		return false
+	}
+	if fuzzy && entry.HasFlag("fuzzy") {
+		return true
+	}
+	if entry.MsgIdPlural == "" {
+		return entry.MsgStr == ""
+	}
+	if len(entry.MsgPlurals) < nplurals {
+		return true
+	}
+	for _, plural := range entry.MsgPlurals {
+		if plural == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// CatalogPluralForms returns the plural rule of a catalog translated into
+// language to: the one of its Plural-Forms header field, or else the CLDR
+// rule of the language. It fails for a language without a known rule.
+func CatalogPluralForms(entries []po.PoEntry, to string) (*po.PluralForms, error) {
+	for _, entry := range entries {
+		if !entry.IsHeader() {
+			continue
+		}
+		if forms, err := po.ParsePluralForms(entry.HeaderField("Plural-Forms")); err == nil {
+			return forms, nil
+		}
+		break
+	}
+
+	forms, ok := po.PluralFormsFor(to)
+	if !ok {
+		return nil, fmt.Errorf("no plural rule is known for %q, set the Plural-Forms header field of the catalog", to)
+	}
+	return forms, nil
+}
+
+// TranslateCatalog fills the entries of a gettext catalog that need a
+// translation into language to, as reported by NeedsTranslation, in
+// batches. Only empty msgstr are filled, unless a fuzzy entry is
+// translated again. Every other entry and field is left as it is. Machine
+// translations are flagged fuzzy for review, while messages of white space
+// only are copied as they are. Entries whose translation failed are
+// reported to OnError and left untranslated. It returns the number of
+// entries translated.
+//
+// Plural entries get a translation for every form of the rule returned by
+// CatalogPluralForms, and TranslateCatalog fails without one if the
+// catalog has plural entries. Each one is translated from the source form
+// of a sample number of its category, with the number in place of the
+// count placeholder, e.g. "5 files" for "%d files". Without a known rule
+// for the source language, the English one picks the source forms and a
+// warning is reported to OnError.
+func (e *Engine) TranslateCatalog(ctx context.Context, entries []po.PoEntry, to string, fuzzy bool) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The plural rules matter to plural entries only.
+	var (
+		nplurals    int
+		samples     []int
+		sourceForms *po.PluralForms
+	)
+	if HasPlurals(entries) {
+		forms, err := CatalogPluralForms(entries, to)
+		if err != nil {
+			return 0, err
+		}
+		nplurals, samples = forms.NPlurals, forms.Samples()
+
+		var ok bool
+		if sourceForms, ok = po.PluralFormsFor(e.From); !ok {
+			e.report(fmt.Errorf("no plural rule is known for %q, using the English one to pick the source forms", e.From))
+			sourceForms, _ = po.PluralFormsFor("en")
+		}
+	}
+
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		translated = make(map[int]bool)
+		units      []catalogUnit
+	)
+	apply := func(unit catalogUnit, text string) {
+		mu.Lock()
+		defer mu.Unlock()
+		unit.apply(&entries[unit.entry], unit.prefix+strings.Trim(text, "\n")+unit.suffix)
+		translated[unit.entry] = true
+	}
+
+	batcher := NewBatcher(e.BatchLines, e.BatchChars)
+	dispatch := func() error {
+		batch := batcher.Flush()
+		if len(batch.Records) == 0 {
+			return nil
+		}
+		batchUnits := make([]catalogUnit, len(batch.Records))
+		for i, rec := range batch.Records {
+			batchUnits[i] = units[rec.Line]
+		}
+
+		// Acquire a slot from the concurrency controller.
+		if err := e.Concurrency.Acquire(ctx); err != nil {
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			var err error
+
+			// Release the slot with the outcome of the translation when done.
+			defer func() { e.Concurrency.Release(err) }()
+			defer wg.Done()
+
+			var results []translator.Result
+			results, err = translator.TranslateBatch(ctx, e.Translator, translator.Request{
+				From:     e.From,
+				To:       to,
+				Segments: batch.Sources(),
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				e.report(err)
+				return
+			}
+			for i, unit := range batchUnits {
+				apply(unit, results[i].Text)
+			}
+		}()
+		return nil
+	}
+
+	add := func(unit catalogUnit, source string) error {
+		text := strings.TrimLeft(source, "\n")
+		unit.prefix = source[:len(source)-len(text)]
+		core := strings.TrimRight(text, "\n")
+		unit.suffix = text[len(core):]
+		if strings.TrimSpace(core) == "" {
+			// Nothing to translate: copy the message without flagging or
+			// counting the entry.
+			mu.Lock()
+			defer mu.Unlock()
+			unit.apply(&entries[unit.entry], source)
+			return nil
+		}
+
+		units = append(units, unit)
+		rec := output.Record{Line: len(units) - 1, Source: core}
+
+		// Messages spanning several lines are sent on their own, as
+		// batches are packed into one text line by line for some
+		// providers.
+		multiline := strings.Contains(core, "\n")
+		if multiline || !batcher.Fits(rec) {
+			if err := dispatch(); err != nil {
+				return err
+			}
+		}
+		batcher.Add(rec, nil, nil)
+		if multiline || batcher.Full() {
+			return dispatch()
+		}
+		return nil
+	}
+
+	var err error
+	for i := range entries {
+		entry := entries[i]
+		if !NeedsTranslation(entry, nplurals, fuzzy) {
+			continue
+		}
+		overwrite := fuzzy && entry.HasFlag("fuzzy")
+		if entry.MsgIdPlural == "" {
+			if err = add(catalogUnit{entry: i, slot: -1, overwrite: overwrite}, entry.MsgId); err != nil {
+				break
+			}
+			continue
+		}
+
+		for slot, sample := range samples {
+			if slot < len(entry.MsgPlurals) && entry.MsgPlurals[slot] != "" && !overwrite {
+				continue
+			}
+			unit, source := pluralUnit(entry, sample, sourceForms)
+			unit.entry, unit.slot, unit.overwrite = i, slot, overwrite
+			if err = add(unit, source); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = dispatch()
+	}
+
+	// Abort in-flight requests when stopping early and wait for all
+	// goroutines to finish.
+	if err != nil {
+		cancel()
+	}
+	wg.Wait()
+
+	for i := range translated {
+		entries[i].AddFlag("fuzzy")
+	}
+	return len(translated), err
+}
+
+// HasPlurals reports whether entries hold a plural message that is not
+// obsolete.
+func HasPlurals(entries []po.PoEntry) bool {
+	for _, entry := range entries {
+		if entry.MsgIdPlural != "" && !entry.Obsolete {
+			return true
+		}
+	}
+	return false
+}
+
+// pluralUnit returns the unit and source text translating the form of a
+// plural entry sample stands for: the source form of the sample, with the
+// sample in place of the count placeholder if the message has exactly
+// one. A negative sample, for a form no number selects, sends the plural
+// source as it is.
+func pluralUnit(entry po.PoEntry, sample int, sourceForms *po.PluralForms) (catalogUnit, string) {
+	if sample < 0 {
+		return catalogUnit{}, entry.MsgIdPlural
+	}
+	source := entry.MsgIdPlural
+	if sourceForms.Index(sample) == 0 {
+		source = entry.MsgId
+	}
+
+	var placeholders []string
+	for _, match := range countPlaceholder.FindAllString(source, -1) {
+		if match != "%%" {
+			placeholders = append(placeholders, match)
+		}
+	}
+	if len(placeholders) != 1 {
+		return catalogUnit{}, source
+	}
+	unit := catalogUnit{sample: strconv.Itoa(sample), placeholder: placeholders[0]}
+	return unit, strings.Replace(source, unit.placeholder, unit.sample, 1)
+}
+
+// apply sets the translation of the unit in entry: the msgstr of a
+// singular entry, or the msgstr[n] of a plural one the unit stands for.
+// Translations already present are kept unless the unit overwrites them.
+func (u catalogUnit) apply(entry *po.PoEntry, text string) {
+	if u.placeholder != "" {
+		text = replaceNumber(text, u.sample, u.placeholder)
+	}
+	if u.slot < 0 {
+		if entry.MsgStr == "" || u.overwrite {
+			entry.MsgStr = text
+		}
+		return
+	}
+
+	for len(entry.MsgPlurals) <= u.slot {
+		entry.MsgPlurals = append(entry.MsgPlurals, "")
+	}
+	if entry.MsgPlurals[u.slot] == "" || u.overwrite {
+		entry.MsgPlurals[u.slot] = text
+	}
+}
+
+// replaceNumber replaces the first number in text equal to number, in the
+// digits of any script, with placeholder. It returns text unchanged if the
+// number is missing, e.g. because it was spelled out.
+func replaceNumber(text, number, placeholder string) string {
+	start := -1
+	var digits strings.Builder
+	for i, r := range text + " " {
+		if unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+				digits.Reset()
+			}
+			digits.WriteByte(byte('0' + digitValue(r)))
+			continue
+		}
+		if start >= 0 && digits.String() == number {
+			return text[:start] + placeholder + text[i:]
+		}
+		start = -1
+	}
+	return text
+}
+
+// digitValue returns the value of the decimal digit r of any script. The
+// digits of a script are consecutive code points from zero to nine.
+func digitValue(r rune) int {
+	n := 0
+	for unicode.IsDigit(r - rune(n) - 1) {
+		n++
+	}
+	return n % 10
+}
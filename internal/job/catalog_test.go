@@ -0,0 +1,140 @@
+package job
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mshafiee/translate/internal/po"
+	"github.com/mshafiee/translate/internal/ratelimit"
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+func TestTranslateCatalog(t *testing.T) {
+	entries := []po.PoEntry{
+		{MsgStr: "Content-Type: text/plain; charset=UTF-8\n"},
+		{TranslatorComments: []string{"Keep it short."}, MsgId: "open", References: []string{"a.c:1"}},
+		{MsgId: "saved", MsgStr: "enregistré"},
+		{MsgId: "stale", MsgStr: "périmé", Flags: []string{"fuzzy", "c-format"}},
+		{MsgId: "\nfirst\nsecond\n"},
+		{MsgId: "%d file", MsgIdPlural: "%d files", MsgPlurals: []string{"%d fichier", ""}},
+		{MsgId: "broken"},
+		{MsgId: "gone", Obsolete: true},
+		{MsgId: " \n"},
+	}
+
+	var mu sync.Mutex
+	var errs []error
+	engine := NewEngine(&targetTranslator{fail: "broken"}, ratelimit.NewAdaptive(ratelimit.Limits{MaxConcurrency: 2}), "en")
+	engine.BatchLines = 1
+	engine.OnError = func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}
+
+	translated, err := engine.TranslateCatalog(context.Background(), entries, "fr", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if translated != 4 {
+		t.Errorf("translated %d entries, want 4", translated)
+	}
+	if len(errs) == 0 {
+		t.Error("the failed translation was not reported")
+	}
+
+	want := []po.PoEntry{
+		{MsgStr: "Content-Type: text/plain; charset=UTF-8\n"},
+		{TranslatorComments: []string{"Keep it short."}, MsgId: "open", MsgStr: "fr:OPEN", References: []string{"a.c:1"}, Flags: []string{"fuzzy"}},
+		{MsgId: "saved", MsgStr: "enregistré"},
+		{MsgId: "stale", MsgStr: "fr:STALE", Flags: []string{"fuzzy", "c-format"}},
+		{MsgId: "\nfirst\nsecond\n", MsgStr: "\nfr:FIRST\nSECOND\n", Flags: []string{"fuzzy"}},
+		{MsgId: "%d file", MsgIdPlural: "%d files", MsgPlurals: []string{"%d fichier", "fr:%d FILES"}, Flags: []string{"fuzzy"}},
+		{MsgId: "broken"},
+		{MsgId: "gone", Obsolete: true},
+		{MsgId: " \n", MsgStr: " \n"},
+	}
+	for i := range want {
+		if !reflect.DeepEqual(entries[i], want[i]) {
+			t.Errorf("entry %d:\n got %+v\nwant %+v", i, entries[i], want[i])
+		}
+	}
+}
+
+// digitTranslator answers with the numbers of the segments in Persian
+// digits.
+type digitTranslator struct{}
+
+func (digitTranslator) Name() string { return "digits" }
+
+func (digitTranslator) Translate(ctx context.Context, req translator.Request) ([]translator.Result, error) {
+	var results []translator.Result
+	for _, s := range req.Segments {
+		text := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return '۰' + r - '0'
+			}
+			return r
+		}, s)
+		results = append(results, translator.Result{Text: "[" + text + "]"})
+	}
+	return results, nil
+}
+
+func TestTranslateCatalogPluralForms(t *testing.T) {
+	entries := []po.PoEntry{
+		{MsgStr: "Content-Type: text/plain; charset=UTF-8\nPlural-Forms: nplurals=INTEGER; plural=EXPRESSION;\n"},
+		{MsgId: "%d file", MsgIdPlural: "%d files", Flags: []string{"c-format"}},
+		{MsgId: "One file", MsgIdPlural: "%d of %d files"},
+	}
+	engine := NewEngine(digitTranslator{}, ratelimit.NewAdaptive(ratelimit.Limits{MaxConcurrency: 1}), "en")
+	if _, err := engine.TranslateCatalog(context.Background(), entries, "ru", false); err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"[%d file]", "[%d files]", "[%d files]"},
+		{"[One file]", "[%d of %d files]", "[%d of %d files]"},
+	}
+	for i, plurals := range want {
+		if got := entries[i+1].MsgPlurals; !reflect.DeepEqual(got, plurals) {
+			t.Errorf("entry %d: msgstr[n] %q, want %q", i+1, got, plurals)
+		}
+	}
+}
+
+func TestTranslateCatalogUnknownPluralForms(t *testing.T) {
+	engine := NewEngine(digitTranslator{}, ratelimit.NewAdaptive(ratelimit.Limits{MaxConcurrency: 1}), "en")
+
+	entries := []po.PoEntry{{MsgId: "%d file", MsgIdPlural: "%d files"}}
+	if _, err := engine.TranslateCatalog(context.Background(), entries, "xx", false); err == nil {
+		t.Error("translated plural entries into a language without a known plural rule")
+	}
+	if entries[0].MsgPlurals != nil {
+		t.Errorf("translated %q", entries[0].MsgPlurals)
+	}
+
+	entries = []po.PoEntry{{MsgId: "file"}}
+	if translated, err := engine.TranslateCatalog(context.Background(), entries, "xx", false); err != nil || translated != 1 {
+		t.Errorf("catalog without plural entries: translated %d, %v", translated, err)
+	}
+}
+
+func TestNeedsTranslation(t *testing.T) {
+	fuzzy := po.PoEntry{MsgId: "a", MsgStr: "b", Flags: []string{"fuzzy"}}
+	if NeedsTranslation(fuzzy, 2, false) {
+		t.Error("a fuzzy entry needs a translation without retranslating fuzzy ones")
+	}
+	if !NeedsTranslation(fuzzy, 2, true) {
+		t.Error("a fuzzy entry needs no translation when retranslating fuzzy ones")
+	}
+	if !NeedsTranslation(po.PoEntry{MsgId: "a", MsgIdPlural: "as"}, 2, false) {
+		t.Error("a plural entry without msgstr[n] needs no translation")
+	}
+	if !NeedsTranslation(po.PoEntry{MsgId: "a", MsgIdPlural: "as", MsgPlurals: []string{"b", "bs"}}, 3, false) {
+		t.Error("a plural entry missing a form needs no translation")
+	}
+}
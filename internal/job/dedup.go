@@ -0,0 +1,129 @@
+package job
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mshafiee/translate/internal/tm"
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+// DedupStats counts the segments seen by a Dedup and how many of them
+// repeated an earlier one.
+type DedupStats struct {
+	Segments int64
+	Repeated int64
+}
+
+// DedupSize is the number of recently seen segments a Dedup remembers.
+// Older repeats are left to the translation memory.
+const DedupSize = 10000
+
+// Dedup translates every distinct segment of a job once. Segments are
+// compared in their normalized form, see tm.Normalize, regardless of their
+// context. Repeats of the last DedupSize distinct segments reuse the first
+// translation, waiting for it if it is still in flight. Failed
+// translations are forgotten so a later occurrence tries again.
+type Dedup struct {
+	translator.Translator
+
+	mu       sync.Mutex
+	seen     map[string]*list.Element
+	recent   *list.List // of *dedupEntry, most recently used first
+	segments atomic.Int64
+	repeated atomic.Int64
+}
+
+type dedupEntry struct {
+	key    string
+	done   chan struct{}
+	result translator.Result
+	err    error
+}
+
+// NewDedup wraps t.
+func NewDedup(t translator.Translator) *Dedup {
+	return &Dedup{Translator: t, seen: make(map[string]*list.Element), recent: list.New()}
+}
+
+// Stats returns the segment counters.
+func (d *Dedup) Stats() DedupStats {
+	return DedupStats{Segments: d.segments.Load(), Repeated: d.repeated.Load()}
+}
+
+// Translate implements translator.Translator. Segments seen for the first
+// time are sent to the wrapped translator in a single request.
+func (d *Dedup) Translate(ctx context.Context, req translator.Request) ([]translator.Result, error) {
+	entries := make([]*dedupEntry, len(req.Segments))
+	var owned []*dedupEntry
+	sub := req
+	sub.Segments = nil
+
+	d.mu.Lock()
+	for i, segment := range req.Segments {
+		key := req.From + "\x00" + req.To + "\x00" + tm.Normalize(segment)
+		if elem, ok := d.seen[key]; ok {
+			d.repeated.Add(1)
+			d.recent.MoveToFront(elem)
+			entries[i] = elem.Value.(*dedupEntry)
+			continue
+		}
+
+		entry := &dedupEntry{key: key, done: make(chan struct{})}
+		d.seen[key] = d.recent.PushFront(entry)
+		if d.recent.Len() > DedupSize {
+			d.forget(d.recent.Back())
+		}
+		owned = append(owned, entry)
+		sub.Segments = append(sub.Segments, segment)
+		entries[i] = entry
+	}
+	d.mu.Unlock()
+	d.segments.Add(int64(len(req.Segments)))
+
+	// Translate the segments this call owns before waiting for any other,
+	// so concurrent calls never wait for each other in a cycle.
+	if len(owned) > 0 {
+		translated, err := d.Translator.Translate(ctx, sub)
+		if err == nil && len(translated) != len(owned) {
+			err = translator.ErrSegmentCount
+		}
+
+		d.mu.Lock()
+		for j, entry := range owned {
+			if err != nil {
+				entry.err = err
+				if elem, ok := d.seen[entry.key]; ok && elem.Value == entry {
+					d.forget(elem)
+				}
+			} else {
+				entry.result = translated[j]
+			}
+			close(entry.done)
+		}
+		d.mu.Unlock()
+	}
+
+	results := make([]translator.Result, len(entries))
+	for i, entry := range entries {
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		results[i] = entry.result
+	}
+	return results, nil
+}
+
+// forget drops the segment of elem. Calls waiting for its translation
+// still get it.
+func (d *Dedup) forget(elem *list.Element) {
+	delete(d.seen, elem.Value.(*dedupEntry).key)
+	d.recent.Remove(elem)
+}
@@ -0,0 +1,109 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+type countingTranslator struct {
+	mu   sync.Mutex
+	sent []string
+	fail bool
+}
+
+func (t *countingTranslator) Name() string { return "counting" }
+
+func (t *countingTranslator) Translate(ctx context.Context, req translator.Request) ([]translator.Result, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.fail {
+		t.fail = false
+		return nil, errors.New("unavailable")
+	}
+	var results []translator.Result
+	for _, s := range req.Segments {
+		t.sent = append(t.sent, s)
+		results = append(results, translator.Result{Text: strings.ToUpper(s)})
+	}
+	return results, nil
+}
+
+func TestDedupTranslatesRepeatsOnce(t *testing.T) {
+	provider := &countingTranslator{}
+	dedup := NewDedup(provider)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results, err := dedup.Translate(context.Background(), translator.Request{
+				From: "en", To: "fa", Segments: []string{"Yes.", " Yes.  ", "No."},
+			})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if results[0].Text != "YES." || results[1].Text != "YES." || results[2].Text != "NO." {
+				t.Errorf("unexpected results %+v", results)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(provider.sent) != 2 {
+		t.Errorf("sent %q, want each distinct segment once", provider.sent)
+	}
+	if stats := dedup.Stats(); stats.Segments != 60 || stats.Repeated != 58 {
+		t.Errorf("stats %+v, want 60 segments and 58 repeats", stats)
+	}
+}
+
+func TestDedupRetriesFailures(t *testing.T) {
+	provider := &countingTranslator{fail: true}
+	dedup := NewDedup(provider)
+	req := translator.Request{From: "en", To: "fa", Segments: []string{"again"}}
+
+	if _, err := dedup.Translate(context.Background(), req); err == nil {
+		t.Fatal("the failure was not reported")
+	}
+	results, err := dedup.Translate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Text != "AGAIN" {
+		t.Errorf("unexpected result %+v", results[0])
+	}
+}
+
+func TestDedupForgetsOldSegments(t *testing.T) {
+	provider := &countingTranslator{}
+	dedup := NewDedup(provider)
+	translate := func(segments ...string) {
+		_, err := dedup.Translate(context.Background(), translator.Request{From: "en", To: "fa", Segments: segments})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	translate("first", "second")
+	for i := 0; i < DedupSize-1; i++ {
+		translate(strconv.Itoa(i))
+		// Using a segment keeps it.
+		translate("second")
+	}
+	translate("first", "second")
+
+	if len(provider.sent) != DedupSize+2 || provider.sent[DedupSize+1] != "first" {
+		t.Errorf("sent %d segments ending with %q, want the first one sent again", len(provider.sent), provider.sent[len(provider.sent)-1])
+	}
+	if len(dedup.seen) != DedupSize || dedup.recent.Len() != DedupSize {
+		t.Errorf("remembers %d segments, want %d", len(dedup.seen), DedupSize)
+	}
+}
@@ -0,0 +1,233 @@
+package job
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/mshafiee/translate/internal/output"
+	"github.com/mshafiee/translate/internal/ratelimit"
+	"github.com/mshafiee/translate/internal/translator"
+	"github.com/mshafiee/translate/internal/utils"
+)
+
+// DefaultContextLines is the number of lines before and after each line
+// sent to context aware providers.
+const DefaultContextLines = 2
+
+// Engine runs jobs: it reads an input once, translates batches of its lines
+// into every target language concurrently and writes the results to the
+// outputs of each target in input order. The command line tool and the UI
+// share it, so both translate, fail and write outputs alike.
+type Engine struct {
+	Translator  translator.Translator
+	Concurrency *ratelimit.Adaptive
+	From        string
+
+	// Sentences enables translating every sentence of a line on its own
+	// as well, adding the results as translator notes.
+	Sentences bool
+
+	BatchLines   int
+	BatchChars   int
+	ContextLines int
+
+	// Hold, if set, is called before each line is read. It may block to
+	// pause the job; the job is canceled when it returns an error.
+	Hold func() error
+
+	// OnError, if set, is called with every error that does not stop the
+	// job. Lines whose translation failed are written untranslated, and
+	// the manifest of their target stops before the first one, so
+	// resuming the job translates them again.
+	OnError func(error)
+
+	// OnProgress, if set, is called whenever a line was translated into
+	// the language of target. Like OnError, it is called concurrently from
+	// the goroutines translating the batches.
+	OnProgress func(target *Target)
+}
+
+// NewEngine returns an Engine translating from one language with tr, with
+// the default batch size and context.
+func NewEngine(tr translator.Translator, concurrency *ratelimit.Adaptive, from string) *Engine {
+	return &Engine{
+		Translator:   tr,
+		Concurrency:  concurrency,
+		From:         from,
+		BatchLines:   DefaultBatchLines,
+		BatchChars:   DefaultBatchChars,
+		ContextLines: DefaultContextLines,
+	}
+}
+
+// Run translates the lines of input not completed yet into every target.
+// It returns when all lines were written, or once ctx is done, Hold failed
+// or an output could not be written. Either way the lines written so far
+// are recorded in the manifest of each target, so the job can be resumed.
+func (e *Engine) Run(ctx context.Context, input io.Reader, targets []*Target) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Translate each batch of consecutive lines into each target language
+	// in a separate goroutine.
+	var wg sync.WaitGroup
+	batchers := make([]*Batcher, len(targets))
+	for i := range batchers {
+		batchers[i] = NewBatcher(e.BatchLines, e.BatchChars)
+	}
+	dispatch := func(i int) error {
+		batch := batchers[i].Flush()
+		if len(batch.Records) == 0 {
+			return nil
+		}
+
+		// Acquire a slot from the concurrency controller.
+		if err := e.Concurrency.Acquire(ctx); err != nil {
+			return err
+		}
+
+		wg.Add(1)
+		go e.translate(ctx, &wg, targets[i], batch)
+		return nil
+	}
+
+	err := e.read(ctx, input, targets, batchers, dispatch)
+	for i := range targets {
+		if err != nil {
+			break
+		}
+		err = dispatch(i)
+	}
+
+	// Abort in-flight requests when stopping early and wait for all
+	// goroutines to finish.
+	if err != nil {
+		cancel()
+	}
+	wg.Wait()
+
+	// Record the lines written so far.
+	for _, target := range targets {
+		if checkpointErr := target.Checkpoint(); checkpointErr != nil && err == nil {
+			err = checkpointErr
+		}
+	}
+	return err
+}
+
+// read scans input once for all targets, handing the lines each target
+// still needs to its batcher.
+func (e *Engine) read(ctx context.Context, input io.Reader, targets []*Target, batchers []*Batcher, dispatch func(int) error) error {
+	// Create a scanner to read the file line by line, keeping the
+	// neighbouring lines of each paragraph as context.
+	scanner := utils.NewContextScanner(utils.NewLineReader(input), e.ContextLines)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		if e.Hold != nil {
+			if err := e.Hold(); err != nil {
+				return err
+			}
+		}
+
+		lineNumber++
+		rec := output.Record{Line: lineNumber, Source: scanner.Text()}
+
+		for i, target := range targets {
+			// Skip lines a previous run of the job completed.
+			if target.Manifest.Done(lineNumber) {
+				continue
+			}
+
+			// Wait until the line is close enough to the oldest line not
+			// written yet.
+			if err := target.Reorder.Reserve(ctx, lineNumber); err != nil {
+				return err
+			}
+
+			// Blank lines end a paragraph and are written as they are.
+			if rec.Blank() {
+				if err := dispatch(i); err != nil {
+					return err
+				}
+				if err := target.Reorder.Put(rec); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if !batchers[i].Fits(rec) {
+				if err := dispatch(i); err != nil {
+					return err
+				}
+			}
+			batchers[i].Add(rec, scanner.Before(), scanner.After())
+			if batchers[i].Full() {
+				if err := dispatch(i); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// translate translates a batch of lines into the language of target and
+// hands the results to its reorder buffer.
+func (e *Engine) translate(ctx context.Context, wg *sync.WaitGroup, target *Target, batch Batch) {
+	var err error
+
+	// Release the slot with the outcome of the translation when done.
+	defer func() { e.Concurrency.Release(err) }()
+	defer wg.Done()
+
+	var translated []translator.Result
+	translated, err = translator.TranslateBatch(ctx, e.Translator, translator.Request{
+		From:     e.From,
+		To:       target.To,
+		Segments: batch.Sources(),
+		Before:   batch.Before,
+		After:    batch.After,
+	})
+	if ctx.Err() != nil {
+		// The job was canceled, drop the lines.
+		return
+	}
+	if err != nil {
+		e.report(err)
+		translated = make([]translator.Result, len(batch.Records))
+	}
+
+	for i, rec := range batch.Records {
+		rec.Translation = translated[i].Text
+		rec.Notes = append(rec.Notes, translated[i].Comments...)
+		rec.Failed = err != nil
+		rec.Fuzzy = translated[i].Fuzzy
+
+		if e.Sentences {
+			sentences, sentenceErr := translator.TranslateSentences(ctx, e.Translator, rec.Source, e.From, target.To)
+			if sentenceErr != nil && ctx.Err() == nil {
+				e.report(sentenceErr)
+			}
+			rec.Notes = append(rec.Notes, sentences...)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		if putErr := target.Reorder.Put(rec); putErr != nil {
+			e.report(putErr)
+			return
+		}
+		if e.OnProgress != nil {
+			e.OnProgress(target)
+		}
+	}
+}
+
+func (e *Engine) report(err error) {
+	if e.OnError != nil {
+		e.OnError(err)
+	}
+}
@@ -0,0 +1,184 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mshafiee/translate/internal/ratelimit"
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+// targetTranslator prefixes the upper-cased segments with the target
+// language.
+type targetTranslator struct {
+	fail string
+}
+
+func (t *targetTranslator) Name() string { return "target" }
+
+func (t *targetTranslator) Translate(ctx context.Context, req translator.Request) ([]translator.Result, error) {
+	var results []translator.Result
+	for _, s := range req.Segments {
+		if s == t.fail {
+			return nil, errors.New("unavailable")
+		}
+		results = append(results, translator.Result{Text: req.To + ":" + strings.ToUpper(s)})
+	}
+	return results, nil
+}
+
+func openTargets(t *testing.T, dir, input string, resume bool, languages ...string) []*Target {
+	var targets []*Target
+	for _, to := range languages {
+		settings := Settings{From: "en", To: to, Provider: "target"}
+		target, err := OpenTarget(TargetFolder(dir, to, len(languages)), input, "input", settings, resume)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { target.Close() })
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+func readText(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestEngineTranslatesIntoEveryTarget(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(input, []byte("one\ntwo\n\nthree\nbroken\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	targets := openTargets(t, dir, input, false, "fa", "ar")
+
+	var mu sync.Mutex
+	var errs []error
+	progress := map[string]int{}
+	engine := NewEngine(&targetTranslator{fail: "broken"}, ratelimit.NewAdaptive(ratelimit.Limits{MaxConcurrency: 4}), "en")
+	engine.BatchLines = 1
+	engine.OnError = func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}
+	engine.OnProgress = func(target *Target) {
+		mu.Lock()
+		defer mu.Unlock()
+		progress[target.To]++
+	}
+
+	file, err := os.Open(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := engine.Run(context.Background(), file, targets); err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range targets {
+		if err := target.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := readText(t, filepath.Join(dir, "fa", "input-fa.txt")); got != "fa:ONE\nfa:TWO\n\nfa:THREE\n\n" {
+		t.Errorf("fa output %q", got)
+	}
+	if got := readText(t, filepath.Join(dir, "ar", "input-ar.txt")); got != "ar:ONE\nar:TWO\n\nar:THREE\n\n" {
+		t.Errorf("ar output %q", got)
+	}
+	if len(errs) != 2 {
+		t.Errorf("reported %v, want the failed line once per target", errs)
+	}
+	if progress["fa"] != 4 || progress["ar"] != 4 {
+		t.Errorf("progress %v, want 4 lines per target", progress)
+	}
+	for _, target := range targets {
+		if target.Failed() != 5 || target.Manifest.Completed != 4 {
+			t.Errorf("%s failed at line %d and completed %d lines, want 5 and 4", target.To, target.Failed(), target.Manifest.Completed)
+		}
+	}
+
+	// Resuming translates the failed line again.
+	engine.Translator = &targetTranslator{}
+	targets = openTargets(t, dir, input, true, "fa", "ar")
+	if _, err := file.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Run(context.Background(), file, targets); err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range targets {
+		if err := target.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := readText(t, filepath.Join(dir, "fa", "input-fa.txt")); got != "fa:ONE\nfa:TWO\n\nfa:THREE\nfa:BROKEN\n" {
+		t.Errorf("resumed fa output %q", got)
+	}
+	if got := readText(t, filepath.Join(dir, "ar", "input-ar.txt")); got != "ar:ONE\nar:TWO\n\nar:THREE\nar:BROKEN\n" {
+		t.Errorf("resumed ar output %q", got)
+	}
+}
+
+func TestEngineStopsAndResumes(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(input, []byte("one\ntwo\nthree\nfour\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	concurrency := ratelimit.NewAdaptive(ratelimit.Limits{MaxConcurrency: 1})
+	engine := NewEngine(&targetTranslator{}, concurrency, "en")
+	engine.BatchLines = 1
+
+	canceled := errors.New("canceled")
+	lines := 0
+	engine.Hold = func() error {
+		lines++
+		if lines == 3 {
+			return canceled
+		}
+		return nil
+	}
+
+	targets := openTargets(t, dir, input, false, "fa")
+	file, err := os.Open(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := engine.Run(context.Background(), file, targets); !errors.Is(err, canceled) {
+		t.Fatalf("got %v, want the error of Hold", err)
+	}
+	if err := targets[0].Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The lines written before stopping are kept, the others translated
+	// when resuming.
+	engine.Hold = nil
+	targets = openTargets(t, dir, input, true, "fa")
+	if _, err := file.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Run(context.Background(), file, targets); err != nil {
+		t.Fatal(err)
+	}
+	if err := targets[0].Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readText(t, filepath.Join(dir, "input-fa.txt")); got != "fa:ONE\nfa:TWO\nfa:THREE\nfa:FOUR\n" {
+		t.Errorf("output %q", got)
+	}
+}
@@ -0,0 +1,147 @@
+// Package job keeps track of translation jobs so an interrupted job can be
+// resumed where it stopped.
+package job
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Settings are the parameters a job must be resumed with.
+type Settings struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Provider  string `json:"provider"`
+	Sentences bool   `json:"sentences"`
+}
+
+// Manifest records the input, settings and progress of a job. Output is
+// written in input order, so progress is the number of leading lines
+// completed together with the size of every output file at that point. It
+// is saved in the output folder at each checkpoint. It is safe for
+// concurrent use.
+type Manifest struct {
+	Input     string           `json:"input"`
+	InputHash string           `json:"input_hash"`
+	Settings  Settings         `json:"settings"`
+	Completed int              `json:"completed"`
+	Outputs   map[string]int64 `json:"outputs,omitempty"`
+	Started   time.Time        `json:"started"`
+	Updated   time.Time        `json:"updated"`
+
+	path string
+	mu   sync.Mutex
+}
+
+// ManifestPath returns the location of the manifest of the job translating
+// the file named name into outputFolder.
+func ManifestPath(outputFolder, name string) string {
+	return filepath.Join(outputFolder, name+"-job.json")
+}
+
+// NewManifest starts a manifest at path for translating input with
+// settings, replacing any previous one.
+func NewManifest(path, input string, settings Settings) (*Manifest, error) {
+	hash, err := HashFile(input)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{
+		Input:     input,
+		InputHash: hash,
+		Settings:  settings,
+		Started:   time.Now().UTC(),
+		path:      path,
+	}
+	return m, m.Save()
+}
+
+// LoadManifest reads the manifest at path.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no previous job found in %s", filepath.Dir(path))
+		}
+		return nil, err
+	}
+	m := &Manifest{path: path}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("job manifest %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Check returns an error if the job cannot be resumed for input with
+// settings because either changed since it started.
+func (m *Manifest) Check(input string, settings Settings) error {
+	if m.Settings != settings {
+		return fmt.Errorf("previous job used different settings: %+v", m.Settings)
+	}
+	hash, err := HashFile(input)
+	if err != nil {
+		return err
+	}
+	if hash != m.InputHash {
+		return fmt.Errorf("%s changed since the previous job started", input)
+	}
+	return nil
+}
+
+// Done reports whether line was completed.
+func (m *Manifest) Done(line int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return line <= m.Completed
+}
+
+// Checkpoint records that every line up to line is completed and the
+// outputs have the given sizes, and saves the manifest.
+func (m *Manifest) Checkpoint(line int, outputs map[string]int64) error {
+	m.mu.Lock()
+	m.Completed = line
+	m.Outputs = outputs
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// Save writes the manifest. The file is replaced atomically so a killed
+// process never leaves a truncated manifest behind.
+func (m *Manifest) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Updated = time.Now().UTC()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.path)
+}
+
+// HashFile returns the hex encoded SHA-256 of the file at path.
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
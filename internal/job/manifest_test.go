@@ -0,0 +1,46 @@
+package job
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManifestResume(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(input, []byte("one\ntwo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	settings := Settings{From: "en", To: "fa", Provider: "google"}
+	path := ManifestPath(dir, "input")
+
+	m, err := NewManifest(path, input, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Checkpoint(1, map[string]int64{"txt": 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err = LoadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Check(input, settings); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Done(1) || m.Done(2) || m.Outputs["txt"] != 4 {
+		t.Errorf("completed %d lines with outputs %v, want 1 line and txt at 4", m.Completed, m.Outputs)
+	}
+
+	if err := m.Check(input, Settings{From: "en", To: "ar", Provider: "google"}); err == nil {
+		t.Error("resuming with another target language succeeded")
+	}
+	if err := os.WriteFile(input, []byte("one\nchanged\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Check(input, settings); err == nil {
+		t.Error("resuming a changed input succeeded")
+	}
+}
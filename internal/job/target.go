@@ -0,0 +1,155 @@
+package job
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mshafiee/translate/internal/output"
+	"github.com/mshafiee/translate/internal/po"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// CheckpointInterval is how often the manifest of a target is saved while
+// lines are written.
+const CheckpointInterval = time.Second
+
+// Generator is the X-Generator header field of the catalogs jobs write.
+const Generator = "github.com/mshafiee/translate"
+
+// Target is the part of a job translating into one language: its manifest,
+// its output files and the buffer writing translated lines to them in input
+// order.
+type Target struct {
+	To       string
+	Folder   string
+	Manifest *Manifest
+	Reorder  *output.Reorder
+
+	out            *output.Writer
+	lastCheckpoint time.Time
+	failed         int
+}
+
+// TargetFolder returns the folder of the outputs in language to of a job
+// writing to outputFolder: outputFolder itself when the job has a single
+// target language, a subfolder named after the language otherwise.
+func TargetFolder(outputFolder, to string, targets int) string {
+	if targets == 1 {
+		return outputFolder
+	}
+	return filepath.Join(outputFolder, to)
+}
+
+// HeaderFor returns the header of a catalog of project translated with
+// settings, created at created and revised now. Created may be zero.
+func HeaderFor(settings Settings, project string, created time.Time) po.HeaderInfo {
+	header := po.HeaderInfo{
+		Project:        project,
+		Language:       settings.To,
+		LastTranslator: settings.Provider + " machine translation",
+		Generator:      Generator,
+		Created:        created,
+		Revised:        time.Now(),
+	}
+	header.PluralForms, _ = po.PluralFormsFor(settings.To)
+	if tag, err := language.Parse(settings.To); err == nil {
+		header.LanguageTeam = display.English.Languages().Name(tag)
+	}
+	return header
+}
+
+// OpenTarget starts translating input, the file named name, with settings
+// into folder. When resuming, the previous job in folder is picked up
+// instead and its outputs are continued from the last checkpoint.
+func OpenTarget(folder, input, name string, settings Settings, resume bool) (*Target, error) {
+	if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	path := ManifestPath(folder, name)
+	var manifest *Manifest
+	var err error
+	if resume {
+		manifest, err = LoadManifest(path)
+		if err == nil {
+			err = manifest.Check(input, settings)
+		}
+	} else {
+		manifest, err = NewManifest(path, input, settings)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	header := HeaderFor(settings, name, manifest.Started)
+	out, err := output.Create(output.FilesFor(folder, name, settings.To), header, manifest.Outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Target{
+		To:       settings.To,
+		Folder:   folder,
+		Manifest: manifest,
+		out:      out,
+	}
+	t.Reorder = output.NewReorder(manifest.Completed+1, output.DefaultWindow, t.write)
+	return t, nil
+}
+
+// write is the emit function of the reorder buffer. It saves a checkpoint
+// at most every CheckpointInterval, and none past the first failed line,
+// so resuming the job translates it again.
+func (t *Target) write(rec output.Record) error {
+	if rec.Failed && t.failed == 0 {
+		if err := t.checkpoint(rec.Line - 1); err != nil {
+			return err
+		}
+		t.failed = rec.Line
+	}
+	if err := t.out.Write(rec); err != nil {
+		return err
+	}
+	if t.failed != 0 || time.Since(t.lastCheckpoint) < CheckpointInterval {
+		return nil
+	}
+	t.lastCheckpoint = time.Now()
+	return t.checkpoint(rec.Line)
+}
+
+// Written returns the number of the last line written to the outputs.
+func (t *Target) Written() int {
+	return t.Reorder.Next() - 1
+}
+
+// Failed returns the number of the first line whose translation failed,
+// or 0 if none did.
+func (t *Target) Failed() int {
+	return t.failed
+}
+
+// Checkpoint flushes the outputs and records every line written so far in
+// the manifest, up to the first failed line. It must not be called while
+// records are being put.
+func (t *Target) Checkpoint() error {
+	if t.failed != 0 {
+		_, err := t.out.Flush()
+		return err
+	}
+	return t.checkpoint(t.Written())
+}
+
+func (t *Target) checkpoint(line int) error {
+	offsets, err := t.out.Flush()
+	if err != nil {
+		return err
+	}
+	return t.Manifest.Checkpoint(line, offsets)
+}
+
+// Close flushes and closes the output files. Later calls do nothing.
+func (t *Target) Close() error {
+	return t.out.Close()
+}
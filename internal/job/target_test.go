@@ -0,0 +1,70 @@
+package job
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mshafiee/translate/internal/output"
+)
+
+func TestTargetResume(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(input, []byte("one\ntwo\nthree\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	folder := TargetFolder(dir, "fa", 2)
+	settings := Settings{From: "en", To: "fa", Provider: "google"}
+
+	target, err := OpenTarget(folder, input, "input", settings, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target.Reorder.Put(output.Record{Line: 2, Source: "two", Translation: "TWO"})
+	target.Reorder.Put(output.Record{Line: 1, Source: "one", Translation: "ONE"})
+	if target.Written() != 2 {
+		t.Fatalf("written %d lines, want 2", target.Written())
+	}
+	if err := target.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	// Lines written after the last checkpoint are dropped on resume.
+	target.Reorder.Put(output.Record{Line: 3, Source: "three", Translation: "LOST"})
+	if err := target.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	target, err = OpenTarget(folder, input, "input", settings, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !target.Manifest.Done(2) || target.Manifest.Done(3) || target.Reorder.Next() != 3 {
+		t.Fatalf("resumed at line %d after %d completed, want 3", target.Reorder.Next(), target.Manifest.Completed)
+	}
+	target.Reorder.Put(output.Record{Line: 3, Source: "three", Translation: "THREE"})
+	if err := target.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := os.ReadFile(filepath.Join(dir, "fa", "input-fa.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text) != "ONE\nTWO\nTHREE\n" {
+		t.Errorf("text output %q", text)
+	}
+
+	if _, err := OpenTarget(TargetFolder(dir, "ar", 2), input, "input", Settings{From: "en", To: "ar", Provider: "google"}, true); err == nil {
+		t.Error("resuming a target without a manifest succeeded")
+	}
+}
+
+func TestTargetFolder(t *testing.T) {
+	if got := TargetFolder("out", "fa", 1); got != "out" {
+		t.Errorf("single target folder %q, want out", got)
+	}
+	if got := TargetFolder("out", "fa", 3); got != filepath.Join("out", "fa") {
+		t.Errorf("folder of one of several targets %q, want out/fa", got)
+	}
+}
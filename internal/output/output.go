@@ -0,0 +1,203 @@
+// Package output writes the results of a translation job, in input order,
+// to the CSV, text and .po files of the job in a single streaming pass.
+package output
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mshafiee/translate/internal/po"
+)
+
+// FuzzyNote is the first note of records whose translation is a fuzzy
+// draft.
+const FuzzyNote = "Fuzzy draft from the translation memory"
+
+// Record is the outcome of one input line.
+type Record struct {
+	Line        int
+	Source      string
+	Translation string
+	// Notes are translator comments and sentence by sentence translations,
+	// written as extra CSV columns and .po comments.
+	Notes []string
+	// Failed is set when the line could not be translated and is written
+	// untranslated.
+	Failed bool
+	// Fuzzy is set when the translation is a draft made from a similar
+	// line. The CSV and .po outputs mark it with FuzzyNote.
+	Fuzzy bool
+}
+
+// Blank reports whether the source line holds no text to translate.
+func (r Record) Blank() bool {
+	return strings.TrimSpace(r.Source) == ""
+}
+
+// Files are the paths of the outputs of a job.
+type Files struct {
+	CSV  string
+	Text string
+	PO   string
+}
+
+// FilesFor returns the outputs of translating the file named name into to,
+// inside outputFolder.
+func FilesFor(outputFolder, name, to string) Files {
+	return Files{
+		CSV:  filepath.Join(outputFolder, name+".csv"),
+		Text: filepath.Join(outputFolder, fmt.Sprintf("%s-%s.txt", name, to)),
+		PO:   filepath.Join(outputFolder, name+".po"),
+	}
+}
+
+// Writer writes records to every output of a job:
+//   - the CSV holds line number, source, translation and notes of every
+//     line with text,
+//   - the text file holds one translated line per input line, keeping
+//     blank lines,
+//   - the .po file holds an entry per line with text.
+type Writer struct {
+	csvFile, textFile, poFile *os.File
+
+	csv  *csv.Writer
+	text *bufio.Writer
+	po   *po.Writer
+	poW  *bufio.Writer
+
+	closed bool
+}
+
+// Create creates the outputs, starting the .po file with a header described
+// by header. When offsets, as returned by Flush, are given the existing
+// outputs are truncated to them and appended to instead, so a job resumes
+// after the last checkpoint.
+func Create(files Files, header po.HeaderInfo, offsets map[string]int64) (*Writer, error) {
+	w := &Writer{}
+	var err error
+	if w.csvFile, err = open(files.CSV, offsets, "csv"); err != nil {
+		return nil, err
+	}
+	if w.textFile, err = open(files.Text, offsets, "txt"); err != nil {
+		w.Close()
+		return nil, err
+	}
+	if w.poFile, err = open(files.PO, offsets, "po"); err != nil {
+		w.Close()
+		return nil, err
+	}
+
+	w.csv = csv.NewWriter(w.csvFile)
+	w.text = bufio.NewWriter(w.textFile)
+	w.poW = bufio.NewWriter(w.poFile)
+	if offsets != nil {
+		w.po = po.NewAppendWriter(w.poW)
+		return w, nil
+	}
+	w.po = po.NewWriter(w.poW)
+	if err := w.po.WriteHeader(header); err != nil {
+		w.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// open creates path, or truncates it to its offset when resuming.
+func open(path string, offsets map[string]int64, key string) (*os.File, error) {
+	if offsets == nil {
+		return os.Create(path)
+	}
+	offset, ok := offsets[key]
+	if !ok {
+		return nil, fmt.Errorf("no checkpoint recorded for %s", path)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Truncate(offset); err == nil {
+		_, err = file.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// Write writes rec to every output.
+func (w *Writer) Write(rec Record) error {
+	if _, err := w.text.WriteString(rec.Translation + "\n"); err != nil {
+		return err
+	}
+	if rec.Blank() {
+		return nil
+	}
+
+	row := []string{strconv.Itoa(rec.Line), rec.Source, rec.Translation}
+	if rec.Fuzzy {
+		row = append(row, FuzzyNote)
+	}
+	row = append(row, rec.Notes...)
+	if err := w.csv.Write(row); err != nil {
+		return err
+	}
+	entry, _ := po.FromRecord(row)
+	return w.po.Write(entry)
+}
+
+// Flush writes buffered data to the files and returns their sizes, the
+// offsets to resume from.
+func (w *Writer) Flush() (map[string]int64, error) {
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return nil, err
+	}
+	if err := w.text.Flush(); err != nil {
+		return nil, err
+	}
+	if err := w.poW.Flush(); err != nil {
+		return nil, err
+	}
+
+	offsets := make(map[string]int64)
+	for key, file := range map[string]*os.File{"csv": w.csvFile, "txt": w.textFile, "po": w.poFile} {
+		offset, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		offsets[key] = offset
+	}
+	return offsets, nil
+}
+
+// Close flushes and closes the files. Closing a closed Writer does
+// nothing, so Close may be deferred as well as called to check its error.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	var firstErr error
+	if w.csv != nil {
+		if _, err := w.Flush(); err != nil {
+			firstErr = err
+		}
+	}
+	for _, file := range []*os.File{w.csvFile, w.textFile, w.poFile} {
+		if file == nil {
+			continue
+		}
+		if err := file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,110 @@
+package output
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mshafiee/translate/internal/po"
+)
+
+func TestReorderEmitsInOrder(t *testing.T) {
+	var got []int
+	r := NewReorder(1, 4, func(rec Record) error {
+		got = append(got, rec.Line)
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for line := 1; line <= 20; line++ {
+		if err := r.Reserve(context.Background(), line); err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(line int) {
+			defer wg.Done()
+			time.Sleep(time.Duration(20-line) * time.Millisecond)
+			r.Put(Record{Line: line})
+		}(line)
+	}
+	wg.Wait()
+
+	if len(got) != 20 || r.Next() != 21 || r.Pending() != 0 {
+		t.Fatalf("emitted %v, next %d, %d pending", got, r.Next(), r.Pending())
+	}
+	for i, line := range got {
+		if line != i+1 {
+			t.Fatalf("emitted %v out of order", got)
+		}
+	}
+}
+
+func TestReorderReserveBlocksOutsideWindow(t *testing.T) {
+	r := NewReorder(1, 2, func(Record) error { return nil })
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := r.Reserve(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reserve(ctx, 3); err == nil {
+		t.Fatal("reserved a line outside the window")
+	}
+	r.Put(Record{Line: 1})
+	if err := r.Reserve(context.Background(), 3); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriterResumesFromCheckpoint(t *testing.T) {
+	files := FilesFor(t.TempDir(), "input", "fa")
+	w, err := Create(files, po.HeaderInfo{Language: "fa"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(Record{Line: 1, Source: "one", Translation: "ONE", Notes: []string{"TM 80%: on => ON"}, Fuzzy: true})
+	w.Write(Record{Line: 2})
+	offsets, err := w.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Written after the checkpoint, then the job is interrupted.
+	w.Write(Record{Line: 3, Source: "lost", Translation: "LOST"})
+	w.Close()
+
+	w, err = Create(files, po.HeaderInfo{Language: "fa"}, offsets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(Record{Line: 3, Source: "three", Translation: "THREE"})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("closing again: %v", err)
+	}
+
+	text := read(t, files.Text)
+	if text != "ONE\n\nTHREE\n" {
+		t.Errorf("text output %q", text)
+	}
+	if csv := read(t, files.CSV); csv != "1,one,ONE,"+FuzzyNote+",TM 80%: on => ON\n3,three,THREE\n" {
+		t.Errorf("csv output %q", csv)
+	}
+	catalog := read(t, files.PO)
+	if strings.Count(catalog, `msgid ""`) != 1 || !strings.Contains(catalog, `"Language: fa\n"`) || strings.Contains(catalog, "LOST") ||
+		!strings.Contains(catalog, "# "+FuzzyNote+"\n# TM 80%: on => ON\n") || !strings.Contains(catalog, `msgctxt "00000003"`) {
+		t.Errorf("po output:\n%s", catalog)
+	}
+}
+
+func read(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
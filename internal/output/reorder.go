@@ -0,0 +1,108 @@
+package output
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultWindow is the number of lines that may be in flight ahead of the
+// first line not written yet.
+const DefaultWindow = 1024
+
+// Reorder hands records completed in any order to emit in line order.
+// Reserve bounds how far ahead of the oldest unfinished line work may start,
+// so at most window records are ever buffered. It is safe for concurrent
+// use.
+type Reorder struct {
+	mu       sync.Mutex
+	next     int
+	window   int
+	pending  map[int]Record
+	advanced chan struct{}
+	emit     func(Record) error
+	err      error
+}
+
+// NewReorder returns a Reorder expecting line first next and calling emit
+// for each record in order.
+func NewReorder(first, window int, emit func(Record) error) *Reorder {
+	return &Reorder{
+		next:     first,
+		window:   window,
+		pending:  make(map[int]Record),
+		advanced: make(chan struct{}),
+		emit:     emit,
+	}
+}
+
+// Reserve blocks until line is within the window of lines that may be
+// processed, ctx is done or emitting failed.
+func (r *Reorder) Reserve(ctx context.Context, line int) error {
+	for {
+		r.mu.Lock()
+		if r.err != nil {
+			err := r.err
+			r.mu.Unlock()
+			return err
+		}
+		if line < r.next+r.window {
+			r.mu.Unlock()
+			return nil
+		}
+		advanced := r.advanced
+		r.mu.Unlock()
+
+		select {
+		case <-advanced:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Put adds a completed record and emits every record now in order. It
+// returns the first error of emit, after which no more records are emitted.
+func (r *Reorder) Put(rec Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+	r.pending[rec.Line] = rec
+
+	advanced := false
+	for {
+		next, ok := r.pending[r.next]
+		if !ok {
+			break
+		}
+		delete(r.pending, r.next)
+		if err := r.emit(next); err != nil {
+			r.err = err
+			break
+		}
+		r.next++
+		advanced = true
+	}
+
+	if advanced || r.err != nil {
+		close(r.advanced)
+		r.advanced = make(chan struct{})
+	}
+	return r.err
+}
+
+// Next returns the first line not emitted yet.
+func (r *Reorder) Next() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.next
+}
+
+// Pending returns the number of records waiting for an earlier line.
+func (r *Reorder) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.pending)
+}
@@ -0,0 +1,369 @@
+package po
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+// PluralForms is the plural rule of a catalog, from its Plural-Forms header
+// field: the number of translations of plural entries and the C expression
+// selecting the one for a number n.
+type PluralForms struct {
+	NPlurals int
+	Plural   string
+	expr     pluralExpr
+}
+
+// maxSample is the largest number Samples and PluralFormsFor look at.
+const maxSample = 1000
+
+// cldrPluralForms holds the rules of the languages whose CLDR integer
+// categories take more than comparing n with 0 and 1, and of those without
+// plural forms, which CLDR cannot tell apart from languages it lacks. The
+// forms are in CLDR order, zero, one, two, few, many and other, leaving out
+// those only fractions fall in.
+var cldrPluralForms = map[string]string{
+	"id": "nplurals=1; plural=0;",
+	"ja": "nplurals=1; plural=0;",
+	"km": "nplurals=1; plural=0;",
+	"ko": "nplurals=1; plural=0;",
+	"lo": "nplurals=1; plural=0;",
+	"ms": "nplurals=1; plural=0;",
+	"my": "nplurals=1; plural=0;",
+	"th": "nplurals=1; plural=0;",
+	"vi": "nplurals=1; plural=0;",
+	"zh": "nplurals=1; plural=0;",
+	"ar": "nplurals=6; plural=(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5);",
+	"be": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<12 || n%100>14) ? 1 : 2);",
+	"bs": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<12 || n%100>14) ? 1 : 2);",
+	"cs": "nplurals=3; plural=(n==1 ? 0 : n>=2 && n<=4 ? 1 : 2);",
+	"cy": "nplurals=6; plural=(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n==3 ? 3 : n==6 ? 4 : 5);",
+	"ga": "nplurals=5; plural=(n==1 ? 0 : n==2 ? 1 : n>=3 && n<=6 ? 2 : n>=7 && n<=10 ? 3 : 4);",
+	"he": "nplurals=4; plural=(n==1 ? 0 : n==2 ? 1 : n>10 && n%10==0 ? 2 : 3);",
+	"hr": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<12 || n%100>14) ? 1 : 2);",
+	"is": "nplurals=2; plural=(n%10!=1 || n%100==11);",
+	"lt": "nplurals=3; plural=(n%10==1 && (n%100<11 || n%100>19) ? 0 : n%10>=2 && (n%100<11 || n%100>19) ? 1 : 2);",
+	"lv": "nplurals=3; plural=(n%10==0 || n%100>=11 && n%100<=19 ? 0 : n%10==1 && n%100!=11 ? 1 : 2);",
+	"mk": "nplurals=2; plural=(n%10!=1);",
+	"pl": "nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<12 || n%100>14) ? 1 : 2);",
+	"ro": "nplurals=3; plural=(n==1 ? 0 : n==0 || n%100>=1 && n%100<=19 ? 1 : 2);",
+	"ru": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<12 || n%100>14) ? 1 : 2);",
+	"sk": "nplurals=3; plural=(n==1 ? 0 : n>=2 && n<=4 ? 1 : 2);",
+	"sl": "nplurals=4; plural=(n%100==1 ? 0 : n%100==2 ? 1 : n%100==3 || n%100==4 ? 2 : 3);",
+	"sr": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<12 || n%100>14) ? 1 : 2);",
+	"uk": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<12 || n%100>14) ? 1 : 2);",
+}
+
+// PluralFormsFor returns the plural rule of language lang, e.g. fa or
+// pt-BR, following its CLDR plural categories. It reports false for an
+// unknown language.
+func PluralFormsFor(lang string) (*PluralForms, bool) {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return nil, false
+	}
+	base, _ := tag.Base()
+	if value, ok := cldrPluralForms[base.String()]; ok {
+		forms, err := ParsePluralForms(value)
+		return forms, err == nil
+	}
+
+	// Every other language CLDR knows distinguishes one from other
+	// numbers, where one is either 1 alone or 0 and 1.
+	categories := map[plural.Form][]int{}
+	for n := 0; n <= maxSample; n++ {
+		form := plural.Cardinal.MatchPlural(tag, n, 0, 0, 0, 0)
+		if len(categories[form]) < 3 {
+			categories[form] = append(categories[form], n)
+		}
+	}
+	var value string
+	switch one := fmt.Sprint(categories[plural.One]); {
+	case len(categories) != 2:
+		return nil, false
+	case one == "[1]":
+		value = "nplurals=2; plural=(n != 1);"
+	case one == "[0 1]":
+		value = "nplurals=2; plural=(n > 1);"
+	default:
+		return nil, false
+	}
+	forms, err := ParsePluralForms(value)
+	return forms, err == nil
+}
+
+// ParsePluralForms parses the value of a Plural-Forms header field, e.g.
+// "nplurals=2; plural=(n != 1);".
+func ParsePluralForms(value string) (*PluralForms, error) {
+	var nplurals, rule string
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch strings.TrimSpace(key) {
+		case "nplurals":
+			nplurals = strings.TrimSpace(val)
+		case "plural":
+			rule = strings.TrimSpace(val)
+		}
+	}
+	n, err := strconv.Atoi(nplurals)
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("po: invalid nplurals in plural forms %q", value)
+	}
+	expr, err := parsePluralExpr(rule)
+	if err != nil {
+		return nil, fmt.Errorf("po: plural forms %q: %w", value, err)
+	}
+	return &PluralForms{NPlurals: n, Plural: rule, expr: expr}, nil
+}
+
+// String returns p as the value of a Plural-Forms header field.
+func (p *PluralForms) String() string {
+	return fmt.Sprintf("nplurals=%d; plural=%s;", p.NPlurals, p.Plural)
+}
+
+// Index returns the index of the translation for the number n. Like
+// gettext, it falls back to the first one when the expression yields an
+// index out of range.
+func (p *PluralForms) Index(n int) int {
+	index := p.expr(n)
+	if index < 0 || index >= p.NPlurals {
+		return 0
+	}
+	return index
+}
+
+// Samples returns a representative number for every translation of a
+// plural entry: the smallest positive number the translation is used for,
+// or 0 if only 0 is. The sample is -1 for a translation no number selects.
+func (p *PluralForms) Samples() []int {
+	samples := make([]int, p.NPlurals)
+	for i := range samples {
+		samples[i] = -1
+	}
+	for n := 1; n <= maxSample; n++ {
+		if index := p.Index(n); samples[index] == -1 {
+			samples[index] = n
+		}
+	}
+	if index := p.Index(0); samples[index] == -1 {
+		samples[index] = 0
+	}
+	return samples
+}
+
+// pluralExpr evaluates a plural expression for the number n.
+type pluralExpr func(n int) int
+
+// pluralParser parses the C subset of plural expressions: n, integers,
+// parentheses, the arithmetic, comparison and logical operators and the
+// conditional operator.
+type pluralParser struct {
+	tokens []string
+	pos    int
+}
+
+func parsePluralExpr(rule string) (pluralExpr, error) {
+	tokens, err := pluralTokens(rule)
+	if err != nil {
+		return nil, err
+	}
+	p := &pluralParser{tokens: tokens}
+	expr, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in plural expression", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+var twoCharOperators = []string{"&&", "||", "==", "!=", "<=", ">="}
+
+func pluralTokens(rule string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(rule); {
+		c := rule[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(rule) && rule[j] >= '0' && rule[j] <= '9' {
+				j++
+			}
+			tokens = append(tokens, rule[i:j])
+			i = j
+		case i+1 < len(rule) && contains(twoCharOperators, rule[i:i+2]):
+			tokens = append(tokens, rule[i:i+2])
+			i += 2
+		case strings.IndexByte("n?:<>!+-*/%()", c) >= 0:
+			tokens = append(tokens, rule[i:i+1])
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected %q in plural expression", c)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty plural expression")
+	}
+	return tokens, nil
+}
+
+func (p *pluralParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// binaryOperators lists the binary operators from the lowest precedence to
+// the highest.
+var binaryOperators = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", ">", "<=", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *pluralParser) conditional() (pluralExpr, error) {
+	cond, err := p.binary(0)
+	if err != nil || p.peek() != "?" {
+		return cond, err
+	}
+	p.pos++
+	then, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != ":" {
+		return nil, fmt.Errorf("missing ':' in plural expression")
+	}
+	p.pos++
+	otherwise, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	return func(n int) int {
+		if cond(n) != 0 {
+			return then(n)
+		}
+		return otherwise(n)
+	}, nil
+}
+
+func (p *pluralParser) binary(level int) (pluralExpr, error) {
+	if level == len(binaryOperators) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if !contains(binaryOperators[level], op) {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr(op, left, right)
+	}
+}
+
+func (p *pluralParser) unary() (pluralExpr, error) {
+	token := p.peek()
+	p.pos++
+	switch {
+	case token == "!":
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(n int) int { return boolInt(operand(n) == 0) }, nil
+	case token == "n":
+		return func(n int) int { return n }, nil
+	case token == "(":
+		expr, err := p.conditional()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing ')' in plural expression")
+		}
+		p.pos++
+		return expr, nil
+	case token != "" && token[0] >= '0' && token[0] <= '9':
+		value, err := strconv.Atoi(token)
+		if err != nil {
+			return nil, err
+		}
+		return func(int) int { return value }, nil
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of plural expression")
+	}
+	return nil, fmt.Errorf("unexpected %q in plural expression", token)
+}
+
+func binaryExpr(op string, left, right pluralExpr) pluralExpr {
+	switch op {
+	case "||":
+		return func(n int) int { return boolInt(left(n) != 0 || right(n) != 0) }
+	case "&&":
+		return func(n int) int { return boolInt(left(n) != 0 && right(n) != 0) }
+	case "==":
+		return func(n int) int { return boolInt(left(n) == right(n)) }
+	case "!=":
+		return func(n int) int { return boolInt(left(n) != right(n)) }
+	case "<":
+		return func(n int) int { return boolInt(left(n) < right(n)) }
+	case ">":
+		return func(n int) int { return boolInt(left(n) > right(n)) }
+	case "<=":
+		return func(n int) int { return boolInt(left(n) <= right(n)) }
+	case ">=":
+		return func(n int) int { return boolInt(left(n) >= right(n)) }
+	case "+":
+		return func(n int) int { return left(n) + right(n) }
+	case "-":
+		return func(n int) int { return left(n) - right(n) }
+	case "*":
+		return func(n int) int { return left(n) * right(n) }
+	}
+	// Division by zero yields 0 rather than crashing on a broken header.
+	return func(n int) int {
+		divisor := right(n)
+		if divisor == 0 {
+			return 0
+		}
+		if op == "/" {
+			return left(n) / divisor
+		}
+		return left(n) % divisor
+	}
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package po
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+func TestPluralFormsFollowCLDR(t *testing.T) {
+	languages := []string{"en", "de", "fr", "fa", "pt", "hi", "es"}
+	for lang := range cldrPluralForms {
+		languages = append(languages, lang)
+	}
+	for _, lang := range languages {
+		forms, ok := PluralFormsFor(lang)
+		if !ok {
+			t.Errorf("%s: no plural forms", lang)
+			continue
+		}
+
+		// The translations are in CLDR order of the categories numbers
+		// fall in.
+		tag := language.MustParse(lang)
+		var categories []plural.Form
+		seen := map[plural.Form]bool{}
+		for n := 0; n <= maxSample; n++ {
+			form := plural.Cardinal.MatchPlural(tag, n, 0, 0, 0, 0)
+			if !seen[form] {
+				seen[form] = true
+				categories = append(categories, form)
+			}
+		}
+		sort.Slice(categories, func(i, j int) bool {
+			return (categories[i]+5)%6 < (categories[j]+5)%6
+		})
+		if forms.NPlurals != len(categories) {
+			t.Errorf("%s: %d forms, CLDR has %v", lang, forms.NPlurals, categories)
+			continue
+		}
+		for n := 0; n <= maxSample; n++ {
+			form := plural.Cardinal.MatchPlural(tag, n, 0, 0, 0, 0)
+			if got := forms.Index(n); categories[got] != form {
+				t.Errorf("%s: %d selects form %d, CLDR category is %v", lang, n, got, form)
+				break
+			}
+		}
+	}
+
+	if _, ok := PluralFormsFor("xx"); ok {
+		t.Error("an unknown language has plural forms")
+	}
+}
+
+func TestPluralFormsSamples(t *testing.T) {
+	for _, test := range []struct {
+		lang    string
+		header  string
+		samples []int
+	}{
+		{"en", "nplurals=2; plural=(n != 1);", []int{1, 2}},
+		{"fr", "nplurals=2; plural=(n > 1);", []int{1, 2}},
+		{"ja", "nplurals=1; plural=0;", []int{1}},
+		{"ru", "", []int{1, 2, 5}},
+		{"ar", "", []int{0, 1, 2, 3, 11, 100}},
+	} {
+		forms, ok := PluralFormsFor(test.lang)
+		if !ok {
+			t.Fatalf("%s: no plural forms", test.lang)
+		}
+		if test.header != "" && forms.String() != test.header {
+			t.Errorf("%s: header %q, want %q", test.lang, forms.String(), test.header)
+		}
+		if got := forms.Samples(); !reflect.DeepEqual(got, test.samples) {
+			t.Errorf("%s: samples %v, want %v", test.lang, got, test.samples)
+		}
+	}
+}
+
+func TestParsePluralForms(t *testing.T) {
+	forms, err := ParsePluralForms(" nplurals=3; plural=n%10==1 && n%100!=11 ? 0 : n != 0 ? 1 : 2;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n, want := range map[int]int{0: 2, 1: 0, 11: 1, 21: 0, 7: 1} {
+		if got := forms.Index(n); got != want {
+			t.Errorf("Index(%d) = %d, want %d", n, got, want)
+		}
+	}
+
+	for _, value := range []string{
+		"nplurals=INTEGER; plural=EXPRESSION;",
+		"nplurals=2; plural=(n != 1;",
+		"nplurals=2; plural=n ? 1;",
+		"nplurals=2; plural=n / 0 +;",
+		"nplurals=2;",
+	} {
+		if _, err := ParsePluralForms(value); err == nil {
+			t.Errorf("parsing %q succeeded", value)
+		}
+	}
+}
@@ -1,20 +1,96 @@
 package po
 
 import (
-	"bytes"
+	"bufio"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
-	"text/template"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/width"
 )
 
+// PoEntry is a message of a catalog.
 type PoEntry struct {
-	MsgId      string
-	MsgStr     string
-	MsgCtxt    string
-	MsgPlurals []string
-	Comment    string
+	MsgId   string
+	MsgStr  string
+	MsgCtxt string
+
+	// MsgIdPlural is the plural form of MsgId. Entries having one hold
+	// their translations, msgstr[0] to msgstr[n], in MsgPlurals.
+	MsgIdPlural string
+	MsgPlurals  []string
+
+	// Comments, references and flags preceding the entry.
+	TranslatorComments []string
+	ExtractedComments  []string
+	References         []string
+	Flags              []string
+
+	// Previous strings of a fuzzy entry, from #| comments.
+	PrevMsgCtxt     string
+	PrevMsgId       string
+	PrevMsgIdPlural string
+
+	// Obsolete entries are commented out with #~.
+	Obsolete bool
+}
+
+// IsHeader reports whether e is the header entry of a catalog.
+func (e PoEntry) IsHeader() bool {
+	return e.MsgId == "" && e.MsgCtxt == "" && !e.Obsolete
+}
+
+// HasFlag reports whether e is flagged with flag, e.g. fuzzy.
+func (e PoEntry) HasFlag(flag string) bool {
+	for _, f := range e.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// AddFlag flags e with flag unless it already is.
+func (e *PoEntry) AddFlag(flag string) {
+	if !e.HasFlag(flag) {
+		e.Flags = append(e.Flags, flag)
+	}
+}
+
+// HeaderField returns the value of the field name, e.g. Language, of the
+// header entry e, or "" if it has none.
+func (e PoEntry) HeaderField(name string) string {
+	for _, line := range strings.Split(e.MsgStr, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// SetHeaderField sets the field name of the header entry e to value,
+// adding it after the other fields if missing.
+func (e *PoEntry) SetHeaderField(name, value string) {
+	field := name + ": " + value + "\n"
+	lines := strings.SplitAfter(e.MsgStr, "\n")
+	for i, line := range lines {
+		key, _, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			lines[i] = field
+			e.MsgStr = strings.Join(lines, "")
+			return
+		}
+	}
+	if e.MsgStr != "" && !strings.HasSuffix(e.MsgStr, "\n") {
+		e.MsgStr += "\n"
+	}
+	e.MsgStr += field
 }
 
 func CSVtoPo(inputFile string, outputFile string) error {
@@ -39,7 +115,6 @@ func CSVtoPo(inputFile string, outputFile string) error {
 func parseCsv(reader *csv.Reader) ([]PoEntry, error) {
 	// Read the CSV file line by line and convert each line to a PoEntry struct
 	var entries []PoEntry
-	lineNum := 0
 	for {
 		record, err := reader.Read()
 		if err != nil {
@@ -48,38 +123,46 @@ func parseCsv(reader *csv.Reader) ([]PoEntry, error) {
 			}
 			return nil, err
 		}
-		lineNum++
-		if len(record) < 2 {
-			continue
+		if entry, ok := FromRecord(record); ok {
+			entries = append(entries, entry)
 		}
+	}
+	return entries, nil
+}
+
+// FromRecord converts a row of line number, source text, translation and
+// translator comments to a PoEntry. Entries are flagged fuzzy, being
+// machine translations. It reports false for rows too short to hold a
+// source text.
+func FromRecord(record []string) (PoEntry, bool) {
+	if len(record) < 2 {
+		return PoEntry{}, false
+	}
 
-		var entry PoEntry
+	entry := PoEntry{Flags: []string{"fuzzy"}}
 
-		for i, r := range record {
-			switch i {
-			case 0:
-				lineNo, _ := strconv.Atoi(r)
-				entry.MsgCtxt = fmt.Sprintf("%08d", lineNo)
-				break
-			case 1:
-				entry.MsgId = escape(r)
-				break
-			case 2:
-				entry.MsgStr = escape(r)
-				break
-			default:
-				if len(r) > 0 {
-					entry.Comment += fmt.Sprintf("# %s\n", r)
-					if len(r) > 120 {
-						entry.Comment += fmt.Sprintf("#‌\n")
-					}
+	for i, r := range record {
+		switch i {
+		case 0:
+			lineNo, _ := strconv.Atoi(r)
+			entry.MsgCtxt = fmt.Sprintf("%08d", lineNo)
+			break
+		case 1:
+			entry.MsgId = r
+			break
+		case 2:
+			entry.MsgStr = r
+			break
+		default:
+			if len(r) > 0 {
+				entry.TranslatorComments = append(entry.TranslatorComments, r)
+				if len(r) > 120 {
+					entry.TranslatorComments = append(entry.TranslatorComments, "\u200c")
 				}
 			}
 		}
-
-		entries = append(entries, entry)
 	}
-	return entries, nil
+	return entry, true
 }
 
 func writePo(entries []PoEntry, outputFile string) error {
@@ -90,45 +173,399 @@ func writePo(entries []PoEntry, outputFile string) error {
 	}
 	defer file.Close()
 
-	// Define the template for the .po file
-	templateString := `msgid ""
-msgstr ""
-"Content-Type: text/plain; charset=UTF-8\n"
-"Content-Transfer-Encoding: 8bit\n"
-
-{{range .}}
-{{.Comment}}
-#, fuzzy
-{{if .MsgCtxt}}msgctxt "{{.MsgCtxt}}"{{end}}
-msgid "{{.MsgId}}"
-msgstr "{{.MsgStr}}"
-{{range $index, $plural := .MsgPlurals}}msgid_plural "{{$plural}}"
-msgstr[{{$index}}] ""
-{{end}}
-{{end}}`
-	poTemplate, err := template.New("poTemplate").Parse(templateString)
-	if err != nil {
+	// Write the header followed by the PoEntry structs
+	w := NewWriter(file)
+	if err := w.WriteHeader(HeaderInfo{}); err != nil {
 		return err
 	}
+	for _, entry := range entries {
+		if err := w.Write(entry); err != nil {
+			return err
+		}
+	}
 
-	// Write the PoEntry structs to the output file using the .po template
-	err = poTemplate.Execute(file, entries)
+	return nil
+}
+
+// WriteFile writes entries as the catalog stored at path, with lines
+// wrapped at width. The file is replaced atomically, so a catalog can be
+// updated in place.
+func WriteFile(path string, entries []PoEntry, width int) error {
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
 	if err != nil {
 		return err
 	}
+	defer os.Remove(tmp)
+
+	buf := bufio.NewWriter(file)
+	w := NewWriter(buf)
+	w.Width = width
+	for _, entry := range entries {
+		if err := w.Write(entry); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := buf.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// HeaderInfo describes a catalog in the fields of its header entry. Empty
+// values are left out.
+type HeaderInfo struct {
+	Project        string // Project-Id-Version
+	Language       string
+	PluralForms    *PluralForms
+	LastTranslator string
+	LanguageTeam   string
+	Generator      string    // X-Generator
+	Created        time.Time // POT-Creation-Date
+	Revised        time.Time // PO-Revision-Date
+}
+
+// HeaderDateLayout is the layout of the dates in header fields.
+const HeaderDateLayout = "2006-01-02 15:04-0700"
+
+// fields returns the header fields info describes in the order of
+// gettext, with the MIME fields of a new catalog when mime is set.
+func (info HeaderInfo) fields(mime bool) [][2]string {
+	date := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(HeaderDateLayout)
+	}
+	pluralForms := ""
+	if info.PluralForms != nil {
+		pluralForms = info.PluralForms.String()
+	}
+	fields := [][2]string{
+		{"Project-Id-Version", info.Project},
+		{"POT-Creation-Date", date(info.Created)},
+		{"PO-Revision-Date", date(info.Revised)},
+		{"Last-Translator", info.LastTranslator},
+		{"Language-Team", info.LanguageTeam},
+		{"Language", info.Language},
+	}
+	if mime {
+		fields = append(fields,
+			[2]string{"MIME-Version", "1.0"},
+			[2]string{"Content-Type", "text/plain; charset=UTF-8"},
+			[2]string{"Content-Transfer-Encoding", "8bit"},
+		)
+	}
+	return append(fields,
+		[2]string{"Plural-Forms", pluralForms},
+		[2]string{"X-Generator", info.Generator},
+	)
+}
+
+// NewHeader returns the header entry of a new catalog described by info.
+func NewHeader(info HeaderInfo) PoEntry {
+	var b strings.Builder
+	for _, field := range info.fields(true) {
+		if field[1] != "" {
+			b.WriteString(field[0] + ": " + field[1] + "\n")
+		}
+	}
+	return PoEntry{MsgStr: b.String()}
+}
+
+// UpdateHeader sets the fields of the header entry e that info describes,
+// keeping the others.
+func (e *PoEntry) UpdateHeader(info HeaderInfo) {
+	for _, field := range info.fields(false) {
+		if field[1] != "" {
+			e.SetHeaderField(field[0], field[1])
+		}
+	}
+}
+
+// SetHeader updates the header entry of a catalog with info, adding one
+// described by info at the start if the catalog has none.
+func SetHeader(entries []PoEntry, info HeaderInfo) []PoEntry {
+	for i := range entries {
+		if entries[i].IsHeader() {
+			entries[i].UpdateHeader(info)
+			return entries
+		}
+	}
+	return append([]PoEntry{NewHeader(info)}, entries...)
+}
+
+// DefaultWidth is the default line width of written catalogs, the one of
+// msgcat.
+const DefaultWidth = 79
+
+// Writer writes .po entries one at a time, in the layout of msgcat:
+// entries are separated by a blank line, strings are C-escaped and split
+// after newlines, at spaces and between East Asian wide characters so lines
+// do not exceed Width columns, and so are references. Reading the output
+// with a Reader gives back the entries written.
+type Writer struct {
+	// Width is the maximum line length in columns. Wide characters take
+	// two columns and combining marks none. Words longer than a line are
+	// not split. Zero or less disables wrapping.
+	Width int
+
+	w       io.Writer
+	started bool
+}
+
+// NewWriter returns a Writer writing a new catalog to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{Width: DefaultWidth, w: w}
+}
+
+// NewAppendWriter returns a Writer adding entries to a catalog w already
+// holds entries of.
+func NewAppendWriter(w io.Writer) *Writer {
+	writer := NewWriter(w)
+	writer.started = true
+	return writer
+}
+
+// WriteHeader writes the header entry starting a .po file, described by
+// info.
+func (w *Writer) WriteHeader(info HeaderInfo) error {
+	return w.Write(NewHeader(info))
+}
+
+// Write writes entry.
+func (w *Writer) Write(entry PoEntry) error {
+	var b strings.Builder
+	if w.started {
+		b.WriteString("\n")
+	}
+
+	for _, comment := range entry.TranslatorComments {
+		writeComment(&b, "#", comment)
+	}
+	for _, comment := range entry.ExtractedComments {
+		writeComment(&b, "#.", comment)
+	}
+	w.writeReferences(&b, entry.References)
+	if len(entry.Flags) > 0 {
+		b.WriteString("#, " + strings.Join(sortFlags(entry.Flags), ", ") + "\n")
+	}
+
+	prefix, previous := "", "#| "
+	if entry.Obsolete {
+		prefix, previous = "#~ ", "#~| "
+	}
+	if entry.PrevMsgCtxt != "" {
+		w.writeString(&b, previous, "msgctxt", entry.PrevMsgCtxt)
+	}
+	if entry.PrevMsgId != "" {
+		w.writeString(&b, previous, "msgid", entry.PrevMsgId)
+	}
+	if entry.PrevMsgIdPlural != "" {
+		w.writeString(&b, previous, "msgid_plural", entry.PrevMsgIdPlural)
+	}
 
+	if entry.MsgCtxt != "" {
+		w.writeString(&b, prefix, "msgctxt", entry.MsgCtxt)
+	}
+	w.writeString(&b, prefix, "msgid", entry.MsgId)
+	if entry.MsgIdPlural == "" {
+		w.writeString(&b, prefix, "msgstr", entry.MsgStr)
+	} else {
+		w.writeString(&b, prefix, "msgid_plural", entry.MsgIdPlural)
+		plurals := entry.MsgPlurals
+		if len(plurals) == 0 {
+			plurals = []string{"", ""}
+		}
+		for i, plural := range plurals {
+			w.writeString(&b, prefix, fmt.Sprintf("msgstr[%d]", i), plural)
+		}
+	}
+
+	if _, err := io.WriteString(w.w, b.String()); err != nil {
+		return err
+	}
+	w.started = true
 	return nil
 }
 
+// writeComment writes a comment line per line of text.
+func writeComment(b *strings.Builder, marker, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString(marker)
+		if line != "" {
+			b.WriteString(" " + line)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// writeReferences writes references on as few #: lines as fit the width.
+func (w *Writer) writeReferences(b *strings.Builder, references []string) {
+	if len(references) == 0 {
+		return
+	}
+	b.WriteString("#:")
+	column := 2
+	for _, reference := range references {
+		width := 1 + displayWidth(reference)
+		if w.Width > 0 && column > 2 && column+width > w.Width {
+			b.WriteString("\n#:")
+			column = 2
+		}
+		b.WriteString(" " + reference)
+		column += width
+	}
+	b.WriteString("\n")
+}
+
+// sortFlags returns flags with fuzzy first, where gettext writes it.
+func sortFlags(flags []string) []string {
+	sorted := make([]string, 0, len(flags))
+	for _, flag := range flags {
+		if flag == "fuzzy" {
+			sorted = append([]string{flag}, sorted...)
+		} else {
+			sorted = append(sorted, flag)
+		}
+	}
+	return sorted
+}
+
+// writeString writes a keyword and its string. A string that does not fit
+// on the keyword line, or that holds a newline before its end, starts with
+// an empty string and continues with a line per piece.
+func (w *Writer) writeString(b *strings.Builder, prefix, keyword, value string) {
+	pieces, inline := w.wrap(prefix, keyword, value)
+	if inline {
+		b.WriteString(prefix + keyword + " \"" + pieces[0] + "\"\n")
+		return
+	}
+	b.WriteString(prefix + keyword + " \"\"\n")
+	for _, piece := range pieces {
+		b.WriteString(prefix + "\"" + piece + "\"\n")
+	}
+}
+
+// wrap escapes value and splits it after every newline and at line breaks
+// so the lines holding the pieces fit in the width. It reports whether the
+// string is a single piece fitting on the keyword line.
+func (w *Writer) wrap(prefix, keyword, value string) ([]string, bool) {
+	var lines []string
+	for _, line := range strings.SplitAfter(value, "\n") {
+		if line != "" || len(lines) == 0 {
+			lines = append(lines, escape(line))
+		}
+	}
+	if w.Width <= 0 {
+		return lines, len(lines) == 1
+	}
+	if len(lines) == 1 && len(prefix+keyword)+3+displayWidth(lines[0]) <= w.Width {
+		return lines, true
+	}
+
+	var pieces []string
+	max := w.Width - len(prefix) - 2
+	for _, line := range lines {
+		pieces = append(pieces, split(line, max)...)
+	}
+	return pieces, false
+}
+
+// split splits an escaped line after spaces and between wide characters
+// into pieces of at most max columns, unless a word is longer.
+func split(line string, max int) []string {
+	var pieces []string
+	for displayWidth(line) > max {
+		end, columns := -1, 0
+		prev := rune(-1)
+		for i, r := range line {
+			if prev >= 0 && breaksBetween(prev, r) {
+				end = i
+			}
+			columns += runeWidth(r)
+			if columns > max {
+				break
+			}
+			if r == ' ' {
+				end = i + 1
+			}
+			prev = r
+		}
+		if end < 0 {
+			// The first word is too long, break after it.
+			end = strings.IndexByte(line, ' ') + 1
+		}
+		if end <= 0 || end == len(line) {
+			break
+		}
+		pieces = append(pieces, line[:end])
+		line = line[end:]
+	}
+	return append(pieces, line)
+}
+
+// breaksBetween reports whether a line may break between two characters
+// that are not spaces: next to a wide character, such as a CJK ideograph,
+// unless the second one is punctuation, which stays with the text before
+// it.
+func breaksBetween(prev, next rune) bool {
+	return (runeWidth(prev) == 2 || runeWidth(next) == 2) && !unicode.IsPunct(next)
+}
+
+// displayWidth returns the number of columns s takes in a terminal.
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// runeWidth returns the number of columns r takes in a terminal: two for
+// East Asian wide and fullwidth characters, none for combining marks and
+// format characters, one otherwise.
+func runeWidth(r rune) int {
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	return 1
+}
+
+// escape returns s as the contents of a C string literal.
 func escape(s string) string {
-	var buf bytes.Buffer
+	var b strings.Builder
 	for _, c := range s {
 		switch c {
-		case '"':
-			buf.WriteString("'")
+		case '"', '\\':
+			b.WriteRune('\\')
+			b.WriteRune(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\a':
+			b.WriteString(`\a`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\v':
+			b.WriteString(`\v`)
 		default:
-			buf.WriteRune(c)
+			b.WriteRune(c)
 		}
 	}
-	return buf.String()
+	return b.String()
 }
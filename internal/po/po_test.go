@@ -0,0 +1,191 @@
+package po
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// canonical is a catalog in the layout msgcat writes at the default width.
+const canonical = `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"Content-Transfer-Encoding: 8bit\n"
+
+# Translator note
+#
+#. Shown on the toolbar
+#: src/toolbar/buttons.c:120 src/toolbar/menu.c:401 src/app/main.c:77
+#: src/app/window.c:1234
+#, fuzzy, c-format
+#| msgid "Open the file"
+msgctxt "toolbar"
+msgid ""
+"Open the selected file in a new window, keeping the current one open in the "
+"background"
+msgstr ""
+"Say \"hi\"\tto C:\\dir\n"
+"and bye\n"
+
+msgid "Ends with a newline\n"
+msgstr "Termine par un saut de ligne\n"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d fichier"
+msgstr[1] "%d fichiers"
+
+#~| msgid "old"
+#~ msgid "Removed"
+#~ msgstr ""
+#~ "Supprimé, avec une traduction assez longue pour dépasser la largeur de la "
+#~ "ligne"
+`
+
+func TestWriterRoundTrip(t *testing.T) {
+	entries, err := NewReader(strings.NewReader(canonical)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, entry := range entries {
+		if err := w.Write(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if buf.String() != canonical {
+		t.Errorf("wrote\n%s\nwant\n%s", buf.String(), canonical)
+	}
+}
+
+func TestWriterEscapesStrings(t *testing.T) {
+	entries := []PoEntry{
+		{
+			TranslatorComments: []string{"two\nlines"},
+			Flags:              []string{"fuzzy"},
+			MsgCtxt:            "00000001",
+			MsgId:              `He said "hi" to C:\Users` + "\tand left.\r\n\n",
+			MsgStr:             strings.Repeat("word ", 40),
+		},
+		{MsgId: "", MsgStr: "a\x07b\x08c\x0cd\x0be"},
+	}
+
+	for _, width := range []int{DefaultWidth, 20, 0} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		w.Width = width
+		for _, entry := range entries {
+			if err := w.Write(entry); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if width > 0 && len(line) > width {
+				t.Errorf("width %d: line %q is too long", width, line)
+			}
+		}
+
+		got, err := NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := append([]PoEntry(nil), entries...)
+		want[0].TranslatorComments = []string{"two", "lines"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("width %d: read back\n%+v\nwant\n%+v", width, got, want)
+		}
+	}
+}
+
+func TestWriterWrapsWideCharacters(t *testing.T) {
+	entry := PoEntry{MsgId: "message", MsgStr: strings.Repeat("翻訳されたテキスト。", 6)}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.Width = 30
+	if err := w.Write(entry); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) < 5 {
+		t.Errorf("wrote %d lines, want the string wrapped:\n%s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		if displayWidth(line) > w.Width {
+			t.Errorf("line %q is %d columns wide", line, displayWidth(line))
+		}
+		if strings.HasPrefix(line, `"。`) {
+			t.Errorf("line %q starts with punctuation", line)
+		}
+	}
+
+	got, err := NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].MsgStr != entry.MsgStr {
+		t.Errorf("read back %+v", got)
+	}
+}
+
+func TestAppendWriterSeparatesEntries(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).WriteHeader(HeaderInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewAppendWriter(&buf).Write(PoEntry{MsgId: "a", MsgStr: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[1].MsgId != "a" {
+		t.Errorf("read %+v, want the header and the appended entry", entries)
+	}
+}
+
+func TestHeader(t *testing.T) {
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	forms, _ := PluralFormsFor("fa")
+	info := HeaderInfo{
+		Project:        "app",
+		Language:       "fa",
+		PluralForms:    forms,
+		LastTranslator: "google machine translation",
+		LanguageTeam:   "Persian",
+		Generator:      "translate",
+		Created:        created,
+		Revised:        created.Add(time.Hour),
+	}
+	want := "Project-Id-Version: app\n" +
+		"POT-Creation-Date: 2024-03-01 09:30+0000\n" +
+		"PO-Revision-Date: 2024-03-01 10:30+0000\n" +
+		"Last-Translator: google machine translation\n" +
+		"Language-Team: Persian\n" +
+		"Language: fa\n" +
+		"MIME-Version: 1.0\n" +
+		"Content-Type: text/plain; charset=UTF-8\n" +
+		"Content-Transfer-Encoding: 8bit\n" +
+		"Plural-Forms: nplurals=2; plural=(n > 1);\n" +
+		"X-Generator: translate\n"
+	if got := NewHeader(info).MsgStr; got != want {
+		t.Errorf("new header\n%s\nwant\n%s", got, want)
+	}
+
+	entries := SetHeader([]PoEntry{{MsgId: "a"}}, HeaderInfo{Language: "fa"})
+	if len(entries) != 2 || !entries[0].IsHeader() || entries[0].HeaderField("Language") != "fa" {
+		t.Errorf("added header %+v", entries)
+	}
+
+	entries = []PoEntry{{MsgStr: "Project-Id-Version: app 1.0\nLanguage: \nContent-Type: text/plain; charset=ISO-8859-1\n"}}
+	entries = SetHeader(entries, HeaderInfo{Language: "de", Generator: "translate"})
+	want = "Project-Id-Version: app 1.0\nLanguage: de\nContent-Type: text/plain; charset=ISO-8859-1\nX-Generator: translate\n"
+	if len(entries) != 1 || entries[0].MsgStr != want {
+		t.Errorf("updated header %q, want %q", entries[0].MsgStr, want)
+	}
+}
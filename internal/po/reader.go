@@ -0,0 +1,386 @@
+package po
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mshafiee/translate/internal/utils"
+)
+
+// Reader reads the entries of a PO or POT catalog in the format described
+// in the GNU gettext manual: translator and extracted comments, references,
+// flags, previous strings, msgctxt, msgid, msgid_plural, msgstr and
+// msgstr[n] keywords with strings split over several lines, and obsolete
+// entries commented out with #~. The header is returned as the entry with
+// an empty msgid.
+type Reader struct {
+	lines  *utils.LineReader
+	line   string
+	lineNo int
+	peeked bool
+	done   bool
+}
+
+// NewReader returns a Reader reading from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{lines: utils.NewLineReader(r)}
+}
+
+// ReadFile reads every entry of the catalog stored at path.
+func ReadFile(path string) ([]PoEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return NewReader(file).ReadAll()
+}
+
+// ReadAll reads the remaining entries.
+func (r *Reader) ReadAll() ([]PoEntry, error) {
+	var entries []PoEntry
+	for {
+		entry, err := r.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// poLine is a line of a catalog taken apart.
+type poLine struct {
+	kind     lineKind
+	text     string // comment text, or the string of keyword and string lines
+	keyword  string
+	index    int // plural form of msgstr[n]
+	obsolete bool
+	previous bool
+}
+
+type lineKind int
+
+const (
+	blankLine lineKind = iota
+	translatorComment
+	extractedComment
+	referenceComment
+	flagComment
+	keywordLine
+	stringLine
+)
+
+// Read returns the next entry, or io.EOF after the last one.
+func (r *Reader) Read() (PoEntry, error) {
+	var entry PoEntry
+	var last *string // the string continued by following string lines
+	var started, hasMsgid, hasMsgstr bool
+
+	for {
+		text, ok := r.peek()
+		if !ok {
+			break
+		}
+		line, err := parseLine(text)
+		if err != nil {
+			return PoEntry{}, fmt.Errorf("po: line %d: %w", r.lineNo, err)
+		}
+
+		// A comment, previous string or new message after a translation
+		// starts the next entry.
+		if hasMsgstr && (line.kind != blankLine && line.kind != stringLine) &&
+			(line.kind != keywordLine || line.previous || line.keyword == "msgctxt" || line.keyword == "msgid") {
+			break
+		}
+		r.peeked = false
+
+		switch line.kind {
+		case blankLine:
+			if hasMsgid {
+				if !hasMsgstr {
+					return PoEntry{}, fmt.Errorf("po: line %d: msgid without msgstr", r.lineNo)
+				}
+				return entry, nil
+			}
+			continue
+		case translatorComment:
+			entry.TranslatorComments = append(entry.TranslatorComments, line.text)
+		case extractedComment:
+			entry.ExtractedComments = append(entry.ExtractedComments, line.text)
+		case referenceComment:
+			entry.References = append(entry.References, strings.Fields(line.text)...)
+		case flagComment:
+			for _, flag := range strings.Split(line.text, ",") {
+				if flag = strings.TrimSpace(flag); flag != "" {
+					entry.Flags = append(entry.Flags, flag)
+				}
+			}
+		case stringLine:
+			if last == nil {
+				return PoEntry{}, fmt.Errorf("po: line %d: string without keyword", r.lineNo)
+			}
+			*last += line.text
+		case keywordLine:
+			entry.Obsolete = entry.Obsolete || line.obsolete
+			if line.previous {
+				last, err = previousField(&entry, line.keyword)
+			} else {
+				last, err = field(&entry, line, hasMsgid)
+			}
+			if err != nil {
+				return PoEntry{}, fmt.Errorf("po: line %d: %w", r.lineNo, err)
+			}
+			*last = line.text
+			hasMsgid = hasMsgid || line.keyword == "msgid" && !line.previous
+			hasMsgstr = hasMsgstr || line.keyword == "msgstr" && !line.previous
+		}
+		started = true
+	}
+	if err := r.lines.Err(); err != nil {
+		return PoEntry{}, err
+	}
+
+	switch {
+	case hasMsgstr:
+		return entry, nil
+	case hasMsgid:
+		return PoEntry{}, fmt.Errorf("po: line %d: msgid without msgstr", r.lineNo)
+	case started:
+		return PoEntry{}, fmt.Errorf("po: line %d: comments without an entry", r.lineNo)
+	}
+	return PoEntry{}, io.EOF
+}
+
+// field returns the field of entry a keyword line sets, checking the
+// keywords come in order.
+func field(entry *PoEntry, line poLine, hasMsgid bool) (*string, error) {
+	switch line.keyword {
+	case "msgctxt":
+		if hasMsgid {
+			return nil, fmt.Errorf("msgctxt after msgid")
+		}
+		return &entry.MsgCtxt, nil
+	case "msgid":
+		if hasMsgid {
+			return nil, fmt.Errorf("duplicate msgid")
+		}
+		return &entry.MsgId, nil
+	case "msgid_plural":
+		if !hasMsgid {
+			return nil, fmt.Errorf("msgid_plural without msgid")
+		}
+		return &entry.MsgIdPlural, nil
+	}
+
+	// msgstr or msgstr[n]
+	if !hasMsgid {
+		return nil, fmt.Errorf("%s without msgid", line.keyword)
+	}
+	if line.index < 0 {
+		if entry.MsgIdPlural != "" {
+			return nil, fmt.Errorf("msgstr of a plural entry needs an index")
+		}
+		return &entry.MsgStr, nil
+	}
+	if line.index != len(entry.MsgPlurals) {
+		return nil, fmt.Errorf("msgstr[%d] out of order", line.index)
+	}
+	entry.MsgPlurals = append(entry.MsgPlurals, "")
+	return &entry.MsgPlurals[line.index], nil
+}
+
+// previousField returns the field of entry a #| line sets.
+func previousField(entry *PoEntry, keyword string) (*string, error) {
+	switch keyword {
+	case "msgctxt":
+		return &entry.PrevMsgCtxt, nil
+	case "msgid":
+		return &entry.PrevMsgId, nil
+	case "msgid_plural":
+		return &entry.PrevMsgIdPlural, nil
+	}
+	return nil, fmt.Errorf("unexpected previous %s", keyword)
+}
+
+// parseLine takes a line apart.
+func parseLine(text string) (poLine, error) {
+	// Comments keep trailing space, so only leading space is dropped.
+	text = strings.TrimLeft(text, " \t")
+	var line poLine
+
+	if strings.HasPrefix(text, "#~") {
+		line.obsolete = true
+		text = strings.TrimSpace(text[2:])
+		if text == "" {
+			return poLine{kind: blankLine}, nil
+		}
+		if !strings.HasPrefix(text, "|") {
+			return parseMessage(line, text)
+		}
+		text = "#" + text
+	}
+
+	switch {
+	case strings.TrimSpace(text) == "":
+		line.kind = blankLine
+		return line, nil
+	case strings.HasPrefix(text, "#|"):
+		line.previous = true
+		return parseMessage(line, strings.TrimSpace(text[2:]))
+	case strings.HasPrefix(text, "#."):
+		line.kind = extractedComment
+	case strings.HasPrefix(text, "#:"):
+		line.kind = referenceComment
+	case strings.HasPrefix(text, "#,"):
+		line.kind = flagComment
+	case strings.HasPrefix(text, "#"):
+		line.kind = translatorComment
+		line.text = strings.TrimPrefix(text[1:], " ")
+		return line, nil
+	default:
+		return parseMessage(line, text)
+	}
+	line.text = strings.TrimPrefix(text[2:], " ")
+	return line, nil
+}
+
+// parseMessage parses a keyword line or a string continuing the previous
+// one.
+func parseMessage(line poLine, text string) (poLine, error) {
+	text = strings.TrimSpace(text)
+	line.kind = stringLine
+	line.index = -1
+	if !strings.HasPrefix(text, `"`) {
+		line.kind = keywordLine
+		end := strings.IndexAny(text, " \t\"")
+		if end < 0 {
+			end = len(text)
+		}
+		line.keyword = text[:end]
+		text = strings.TrimSpace(text[end:])
+
+		switch line.keyword {
+		case "msgctxt", "msgid", "msgid_plural", "msgstr":
+		default:
+			index, ok := pluralIndex(line.keyword)
+			if !ok || line.previous {
+				return poLine{}, fmt.Errorf("unknown keyword %q", line.keyword)
+			}
+			line.keyword = "msgstr"
+			line.index = index
+		}
+	}
+
+	s, err := unquote(text)
+	if err != nil {
+		return poLine{}, err
+	}
+	line.text = s
+	return line, nil
+}
+
+// pluralIndex parses the index n of a msgstr[n] keyword.
+func pluralIndex(keyword string) (int, bool) {
+	if !strings.HasPrefix(keyword, "msgstr[") || !strings.HasSuffix(keyword, "]") {
+		return 0, false
+	}
+	index, err := strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+	return index, err == nil && index >= 0
+}
+
+// unquote returns the value of a C string literal.
+func unquote(text string) (string, error) {
+	if len(text) < 2 || text[0] != '"' || text[len(text)-1] != '"' {
+		return "", fmt.Errorf("malformed string %s", text)
+	}
+	text = text[1 : len(text)-1]
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c == '"' {
+			return "", fmt.Errorf("unescaped quote in string")
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		if i == len(text) {
+			return "", fmt.Errorf("string ends in a backslash")
+		}
+		switch c = text[i]; c {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '\\', '"', '\'', '?':
+			b.WriteByte(c)
+		case 'x':
+			j := i + 1
+			for j < len(text) && j < i+3 && isHexDigit(text[j]) {
+				j++
+			}
+			if j == i+1 {
+				return "", fmt.Errorf("invalid escape \\x")
+			}
+			value, _ := strconv.ParseUint(text[i+1:j], 16, 8)
+			b.WriteByte(byte(value))
+			i = j - 1
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			j := i
+			for j < len(text) && j < i+3 && text[j] >= '0' && text[j] <= '7' {
+				j++
+			}
+			value, err := strconv.ParseUint(text[i:j], 8, 8)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape \\%s", text[i:j])
+			}
+			b.WriteByte(byte(value))
+			i = j - 1
+		default:
+			return "", fmt.Errorf("invalid escape \\%c", c)
+		}
+	}
+	return b.String(), nil
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// peek returns the current line without consuming it. The line is
+// consumed by clearing peeked.
+func (r *Reader) peek() (string, bool) {
+	if r.peeked {
+		return r.line, true
+	}
+	if r.done || !r.lines.Scan() {
+		r.done = true
+		return "", false
+	}
+	r.line = r.lines.Text()
+	if r.lineNo == 0 {
+		r.line = strings.TrimPrefix(r.line, "\ufeff")
+	}
+	r.lineNo++
+	r.peeked = true
+	return r.line, true
+}
@@ -0,0 +1,109 @@
+package po
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const catalog = `# Translation of the app.
+msgid ""
+msgstr ""
+"Project-Id-Version: app 1.0\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#  Keep it short.
+#. Shown on the toolbar
+#: src/toolbar.c:12 src/menu.c:40
+#: src/main.c:7
+#, fuzzy, c-format
+#| msgid "Open %s"
+msgctxt "toolbar"
+msgid "Open %s file"
+msgstr "%s را باز کن"
+
+msgid ""
+"A long message "
+"split over lines\n"
+msgstr "Line with \"quotes\", a tab\t, a backslash \\ and \303\251"
+
+#, c-format
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d پرونده"
+msgstr[1] ""
+"%d پرونده‌ها"
+#~ msgid "Removed"
+#~ msgstr "حذف شد"
+
+#~| msgid "Old"
+#~ msgid "Older"
+#~ msgstr ""
+#~ "قدیمی"
+`
+
+func TestReader(t *testing.T) {
+	entries, err := NewReader(strings.NewReader("\ufeff" + catalog)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PoEntry{
+		{
+			TranslatorComments: []string{"Translation of the app."},
+			MsgStr:             "Project-Id-Version: app 1.0\nContent-Type: text/plain; charset=UTF-8\n",
+		},
+		{
+			TranslatorComments: []string{" Keep it short."},
+			ExtractedComments:  []string{"Shown on the toolbar"},
+			References:         []string{"src/toolbar.c:12", "src/menu.c:40", "src/main.c:7"},
+			Flags:              []string{"fuzzy", "c-format"},
+			PrevMsgId:          "Open %s",
+			MsgCtxt:            "toolbar",
+			MsgId:              "Open %s file",
+			MsgStr:             "%s را باز کن",
+		},
+		{
+			MsgId:  "A long message split over lines\n",
+			MsgStr: "Line with \"quotes\", a tab\t, a backslash \\ and é",
+		},
+		{
+			Flags:       []string{"c-format"},
+			MsgId:       "%d file",
+			MsgIdPlural: "%d files",
+			MsgPlurals:  []string{"%d پرونده", "%d پرونده‌ها"},
+		},
+		{MsgId: "Removed", MsgStr: "حذف شد", Obsolete: true},
+		{PrevMsgId: "Old", MsgId: "Older", MsgStr: "قدیمی", Obsolete: true},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("read %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(entries[i], want[i]) {
+			t.Errorf("entry %d:\n got %+v\nwant %+v", i, entries[i], want[i])
+		}
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	for _, text := range []string{
+		"msgid \"a\"\n\nmsgstr \"b\"\n",
+		"msgstr \"b\"\n",
+		"msgid \"a\"\nmsgstr \"b\n",
+		"msgid \"a\"\nmsgstr \"\\q\"\n",
+		"msgid \"a\"\nmsgid_plural \"as\"\nmsgstr[1] \"b\"\n",
+		"msgid \"a\"\nmsgtxt \"b\"\n",
+		"\"orphan\"\n",
+		"# a comment at the end\n",
+	} {
+		if _, err := NewReader(strings.NewReader(text)).ReadAll(); err == nil {
+			t.Errorf("reading %q succeeded", text)
+		}
+	}
+
+	r := NewReader(strings.NewReader(""))
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("reading an empty catalog returned %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/mshafiee/translate/internal/retry"
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+// Adaptive is a concurrency controller: it halves the number of concurrent
+// workers when the provider throttles and grows it by one after a full
+// window of successful calls.
+type Adaptive struct {
+	// OnChange is called with the new limit whenever it changes.
+	OnChange func(limit int)
+
+	mu        sync.Mutex
+	min       int
+	max       int
+	limit     int
+	inFlight  int
+	successes int
+	throttle  []int
+	wake      chan struct{}
+}
+
+// NewAdaptive returns a controller starting at the maximum concurrency of
+// limits.
+func NewAdaptive(limits Limits) *Adaptive {
+	return &Adaptive{
+		min:      limits.MinConcurrency,
+		max:      limits.MaxConcurrency,
+		limit:    limits.MaxConcurrency,
+		throttle: limits.ThrottleStatus,
+		wake:     make(chan struct{}),
+	}
+}
+
+// Limit returns the current concurrency limit.
+func (a *Adaptive) Limit() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.limit
+}
+
+// Acquire blocks until a worker slot is free or ctx is done.
+func (a *Adaptive) Acquire(ctx context.Context) error {
+	for {
+		a.mu.Lock()
+		if a.inFlight < a.limit {
+			a.inFlight++
+			a.mu.Unlock()
+			return nil
+		}
+		wake := a.wake
+		a.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+		}
+	}
+}
+
+// Release frees a worker slot. err is the outcome of the work done in the
+// slot and drives the limit.
+func (a *Adaptive) Release(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.inFlight--
+	switch {
+	case a.isThrottled(err):
+		a.shrink()
+	case err == nil:
+		a.successes++
+		if a.successes >= a.limit && a.limit < a.max {
+			a.setLimit(a.limit + 1)
+		}
+	}
+	a.broadcast()
+}
+
+// Observe shrinks the limit when attempt was throttled. It is meant to be
+// called from a retry.Policy.OnAttempt hook so the pool shrinks on the first
+// throttling response instead of after all retries failed.
+func (a *Adaptive) Observe(attempt retry.Attempt) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.isThrottlingStatus(attempt.StatusCode) {
+		a.shrink()
+	}
+}
+
+func (a *Adaptive) shrink() {
+	a.setLimit(a.limit / 2)
+}
+
+func (a *Adaptive) setLimit(limit int) {
+	if limit < a.min {
+		limit = a.min
+	}
+	if limit > a.max {
+		limit = a.max
+	}
+	a.successes = 0
+	if limit == a.limit {
+		return
+	}
+	a.limit = limit
+	if a.OnChange != nil {
+		a.OnChange(limit)
+	}
+}
+
+func (a *Adaptive) broadcast() {
+	close(a.wake)
+	a.wake = make(chan struct{})
+}
+
+func (a *Adaptive) isThrottlingStatus(status int) bool {
+	for _, s := range a.throttle {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Adaptive) isThrottled(err error) bool {
+	var httpErr *translator.HTTPError
+	return errors.As(err, &httpErr) && a.isThrottlingStatus(httpErr.StatusCode)
+}
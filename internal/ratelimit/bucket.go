@@ -0,0 +1,55 @@
+// Package ratelimit keeps translation jobs below provider quotas with token
+// buckets for requests per second and characters per minute, and adapts the
+// number of concurrent workers to throttling responses.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mshafiee/translate/internal/retry"
+)
+
+// Bucket is a token bucket refilled at a constant rate.
+type Bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket returns a full bucket refilled with rate tokens per second and
+// holding at most burst tokens.
+func NewBucket(rate, burst float64) *Bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Bucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// Wait takes n tokens, blocking until they are available or ctx is done.
+// Requests larger than the burst size are clamped to it.
+func (b *Bucket) Wait(ctx context.Context, n float64) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if n > b.burst {
+		n = b.burst
+	}
+	b.tokens -= n
+
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	return retry.Sleep(ctx, wait)
+}
@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+// Limits configures the rate limiter and the concurrency controller of a
+// provider. Zero rates are unlimited.
+type Limits struct {
+	RequestsPerSecond float64
+	CharsPerMinute    int
+	MinConcurrency    int
+	MaxConcurrency    int
+	// ThrottleStatus lists the status codes the provider answers when it
+	// rate limits us.
+	ThrottleStatus []int
+}
+
+var defaultThrottleStatus = []int{http.StatusTooManyRequests, http.StatusServiceUnavailable}
+
+// Defaults are conservative limits per provider, used for every value a
+// job does not override.
+var Defaults = map[string]Limits{
+	"google":         {RequestsPerSecond: 5, MinConcurrency: 1, MaxConcurrency: 10, ThrottleStatus: []int{http.StatusForbidden, http.StatusTooManyRequests, http.StatusServiceUnavailable}},
+	"libretranslate": {MinConcurrency: 1, MaxConcurrency: 8},
+	"openai":         {RequestsPerSecond: 2, MinConcurrency: 1, MaxConcurrency: 4},
+	"deepl":          {RequestsPerSecond: 10, CharsPerMinute: 500000, MinConcurrency: 1, MaxConcurrency: 10},
+	"microsoft":      {RequestsPerSecond: 10, CharsPerMinute: 33300, MinConcurrency: 1, MaxConcurrency: 10},
+}
+
+// DefaultLimits are used for providers missing from Defaults.
+var DefaultLimits = Limits{MinConcurrency: 1, MaxConcurrency: 10}
+
+// For returns the limits of provider with every non-zero field of overrides
+// applied on top of its defaults.
+func For(provider string, overrides Limits) Limits {
+	limits, ok := Defaults[provider]
+	if !ok {
+		limits = DefaultLimits
+	}
+	if overrides.RequestsPerSecond != 0 {
+		limits.RequestsPerSecond = overrides.RequestsPerSecond
+	}
+	if overrides.CharsPerMinute != 0 {
+		limits.CharsPerMinute = overrides.CharsPerMinute
+	}
+	if overrides.MinConcurrency != 0 {
+		limits.MinConcurrency = overrides.MinConcurrency
+	}
+	if overrides.MaxConcurrency != 0 {
+		limits.MaxConcurrency = overrides.MaxConcurrency
+	}
+	if len(overrides.ThrottleStatus) != 0 {
+		limits.ThrottleStatus = overrides.ThrottleStatus
+	}
+	if len(limits.ThrottleStatus) == 0 {
+		limits.ThrottleStatus = defaultThrottleStatus
+	}
+	if limits.MinConcurrency < 1 {
+		limits.MinConcurrency = 1
+	}
+	if limits.MaxConcurrency < limits.MinConcurrency {
+		limits.MaxConcurrency = limits.MinConcurrency
+	}
+	return limits
+}
+
+// Limiter throttles requests and characters sent to a provider.
+type Limiter struct {
+	requests *Bucket
+	chars    *Bucket
+}
+
+// NewLimiter creates a Limiter for limits. Requests may burst up to one
+// second worth of requests, characters up to one minute worth.
+func NewLimiter(limits Limits) *Limiter {
+	l := &Limiter{}
+	if limits.RequestsPerSecond > 0 {
+		l.requests = NewBucket(limits.RequestsPerSecond, limits.RequestsPerSecond)
+	}
+	if limits.CharsPerMinute > 0 {
+		l.chars = NewBucket(float64(limits.CharsPerMinute)/60, float64(limits.CharsPerMinute))
+	}
+	return l
+}
+
+// Throttle blocks until another request may be sent. It is meant to be the
+// Throttle of the retry policy of a provider, so every HTTP request and
+// retry counts, however many a Translate call sends.
+func (l *Limiter) Throttle(ctx context.Context) error {
+	if l.requests == nil {
+		return nil
+	}
+	return l.requests.Wait(ctx, 1)
+}
+
+func (l *Limiter) waitChars(ctx context.Context, chars int) error {
+	if l.chars == nil {
+		return nil
+	}
+	return l.chars.Wait(ctx, float64(chars))
+}
+
+type limitedTranslator struct {
+	translator.Translator
+	limiter *Limiter
+}
+
+// Wrap returns a Translator waiting for the characters of every request to
+// t to fit the limits of l. Requests per second are enforced by setting
+// l.Throttle as the Throttle of the retry policy of t.
+func Wrap(t translator.Translator, l *Limiter) translator.Translator {
+	return &limitedTranslator{Translator: t, limiter: l}
+}
+
+func (t *limitedTranslator) Translate(ctx context.Context, req translator.Request) ([]translator.Result, error) {
+	chars := 0
+	for _, segment := range req.Segments {
+		chars += utf8.RuneCountInString(segment)
+	}
+	if err := t.limiter.waitChars(ctx, chars); err != nil {
+		return nil, err
+	}
+	return t.Translator.Translate(ctx, req)
+}
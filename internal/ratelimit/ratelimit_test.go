@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mshafiee/translate/internal/retry"
+	"github.com/mshafiee/translate/internal/translator"
+	_ "github.com/mshafiee/translate/internal/translator/google"
+)
+
+func TestBucketWait(t *testing.T) {
+	b := NewBucket(100, 2)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := b.Wait(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Two tokens are available at once, the other two take 10ms each.
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("four tokens at 100/s with burst 2 took only %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewBucket(0.001, 1).Wait(ctx, 2); err == nil {
+		t.Error("expected an error from a cancelled context")
+	}
+}
+
+func TestAdaptive(t *testing.T) {
+	var changes []int
+	a := NewAdaptive(For("google", Limits{MaxConcurrency: 8}))
+	a.OnChange = func(limit int) { changes = append(changes, limit) }
+
+	a.Observe(retry.Attempt{StatusCode: http.StatusForbidden})
+	a.Observe(retry.Attempt{StatusCode: http.StatusBadRequest})
+	if a.Limit() != 4 {
+		t.Fatalf("got limit %d after throttling, want 4", a.Limit())
+	}
+
+	for i := 0; i < 4; i++ {
+		if err := a.Acquire(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := a.Acquire(ctx); err == nil {
+		t.Fatal("acquired more slots than the limit")
+	}
+
+	a.Release(&translator.HTTPError{StatusCode: http.StatusTooManyRequests})
+	for i := 0; i < 3; i++ {
+		a.Release(nil)
+	}
+	if a.Limit() != 3 {
+		t.Errorf("got limit %d, want 3 after a throttled call and a window of successes", a.Limit())
+	}
+	if len(changes) != 3 || changes[0] != 4 || changes[1] != 2 || changes[2] != 3 {
+		t.Errorf("got changes %v", changes)
+	}
+}
+
+func TestThrottleLimitsEveryRequest(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		hits []time.Time
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits = append(hits, time.Now())
+		mu.Unlock()
+		w.Write([]byte(`[[["Uno.","One.",null,null,10]],null,"en"]`))
+	}))
+	defer srv.Close()
+
+	const rps = 20
+	limiter := NewLimiter(Limits{RequestsPerSecond: rps})
+	policy := retry.DefaultPolicy()
+	policy.Throttle = limiter.Throttle
+	tr, err := translator.New("google", translator.Config{BaseURL: srv.URL, Retry: &policy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr = Wrap(tr, limiter)
+
+	// The google provider sends a request per segment and per sentence.
+	segments := make([]string, 2*rps)
+	for i := range segments {
+		segments[i] = "One. Two. Three."
+	}
+	if _, err := tr.Translate(context.Background(), translator.Request{From: "en", To: "es", Segments: segments}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := translator.TranslateSentences(context.Background(), tr, segments[0], "en", "es"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A burst of one second worth of requests, then rps per second.
+	if len(hits) < 2*rps+3 {
+		t.Fatalf("got %d requests, want at least %d", len(hits), 2*rps+3)
+	}
+	for i, hit := range hits {
+		inSecond := 0
+		for _, other := range hits[i:] {
+			if other.Sub(hit) < time.Second {
+				inSecond++
+			}
+		}
+		if inSecond > 2*rps {
+			t.Fatalf("%d requests within a second of request %d, want at most %d", inSecond, i+1, 2*rps)
+		}
+	}
+	if elapsed, want := hits[len(hits)-1].Sub(hits[0]), time.Duration(len(hits)-rps-1)*time.Second/rps; elapsed < want*9/10 {
+		t.Errorf("%d requests took %v, want at least %v", len(hits), elapsed, want)
+	}
+}
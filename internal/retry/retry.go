@@ -0,0 +1,252 @@
+// Package retry implements the retry policy shared by all translation
+// providers: exponential backoff with jitter, Retry-After support and a
+// configurable set of retryable status codes.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Attempt describes a failed attempt and what happens next.
+type Attempt struct {
+	// Number is the 1-based number of the failed attempt.
+	Number int
+	// MaxAttempts is the configured number of attempts.
+	MaxAttempts int
+	// URL is the requested URL without query, fragment and user
+	// information, which may hold the text sent or credentials.
+	URL string
+	// StatusCode is the answered status, 0 when the request itself failed.
+	StatusCode int
+	// Err is the transport error, nil when a status was answered.
+	Err error
+	// Wait is the delay before the next attempt, 0 when giving up.
+	Wait time.Duration
+}
+
+// Retrying reports whether another attempt follows.
+func (a Attempt) Retrying() bool {
+	return a.Number < a.MaxAttempts
+}
+
+func (a Attempt) String() string {
+	reason := fmt.Sprintf("status %d", a.StatusCode)
+	if a.Err != nil {
+		reason = a.Err.Error()
+	}
+	msg := fmt.Sprintf("attempt %d/%d for %s failed: %s", a.Number, a.MaxAttempts, a.URL, reason)
+	if a.Retrying() {
+		msg += fmt.Sprintf(", retrying in %s", a.Wait.Round(time.Millisecond))
+	}
+	return msg
+}
+
+// Policy controls how requests are retried. The zero value sends every
+// request once.
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int
+	// InitialBackoff is the delay after the first failed attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts, including Retry-After.
+	MaxBackoff time.Duration
+	// Multiplier grows the delay after every failed attempt.
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction, e.g. 0.2 for ±20%.
+	Jitter float64
+	// RetryableStatus lists the status codes worth retrying.
+	RetryableStatus []int
+	// OnAttempt is called after every failed attempt.
+	OnAttempt func(Attempt)
+	// Throttle, if set, is called before every attempt and blocks until the
+	// request may be sent, e.g. to enforce a rate limit.
+	Throttle func(context.Context) error
+}
+
+// DefaultRetryableStatus are the status codes retried by DefaultPolicy.
+var DefaultRetryableStatus = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooEarly,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// DefaultPolicy returns the policy used when none is configured.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:     4,
+		InitialBackoff:  500 * time.Millisecond,
+		MaxBackoff:      30 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.2,
+		RetryableStatus: DefaultRetryableStatus,
+	}
+}
+
+// Retryable reports whether status is one of the retryable status codes.
+func (p Policy) Retryable(status int) bool {
+	for _, s := range p.RetryableStatus {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff returns the delay after the given failed attempt, before jitter.
+func (p Policy) Backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	return time.Duration(backoff)
+}
+
+// Do sends req with client, retrying transport errors and retryable status
+// codes. Every attempt waits for Throttle first. URLs in transport errors
+// and attempts are redacted like Attempt.URL. Request bodies are rewound through req.GetBody. Unless the context
+// is done, Do returns the last response, which may carry a non-2xx status
+// that the caller must handle, or the last transport error. Bodies of
+// discarded responses are drained and closed.
+func (p Policy) Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	maxAttempts := p.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	ctx := req.Context()
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+
+		if p.Throttle != nil {
+			if err := p.Throttle(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := client.Do(req)
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = redact(req.URL)
+		}
+		if err == nil && !p.Retryable(resp.StatusCode) {
+			return resp, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, ctxErr
+		}
+
+		info := Attempt{Number: attempt, MaxAttempts: maxAttempts, URL: redact(req.URL), Err: err}
+		if resp != nil {
+			info.StatusCode = resp.StatusCode
+		}
+		if attempt < maxAttempts {
+			info.Wait = p.wait(attempt, resp)
+		}
+		if p.OnAttempt != nil {
+			p.OnAttempt(info)
+		}
+		if attempt >= maxAttempts {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err := Sleep(ctx, info.Wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// redact returns u without query, fragment and user information.
+func redact(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	redacted.RawQuery = ""
+	redacted.ForceQuery = false
+	redacted.Fragment = ""
+	redacted.RawFragment = ""
+	return redacted.String()
+}
+
+// wait returns the delay before the next attempt, honoring Retry-After.
+func (p Policy) wait(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			if p.MaxBackoff > 0 && d > p.MaxBackoff {
+				d = p.MaxBackoff
+			}
+			return d
+		}
+	}
+
+	backoff := p.Backoff(attempt)
+	if p.Jitter > 0 {
+		backoff += time.Duration(float64(backoff) * p.Jitter * (2*rand.Float64() - 1))
+	}
+	return backoff
+}
+
+// retryAfter parses a Retry-After header holding seconds or an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		d := time.Until(date)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// Sleep waits for d or until ctx is done, whichever happens first.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,134 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDoRetriesAndRewindsBody(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	var attempts []Attempt
+	policy := DefaultPolicy()
+	policy.OnAttempt = func(a Attempt) { attempts = append(attempts, a) }
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader([]byte("payload")))
+	resp, err := policy.Do(srv.Client(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got status %d", resp.StatusCode)
+	}
+	if len(bodies) != 3 || bodies[2] != "payload" {
+		t.Errorf("got bodies %q", bodies)
+	}
+	if len(attempts) != 2 || attempts[0].StatusCode != http.StatusTooManyRequests || attempts[0].Wait != 0 {
+		t.Errorf("got attempts %+v", attempts)
+	}
+}
+
+func TestDoReturnsNonRetryableResponse(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := DefaultPolicy().Do(srv.Client(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized || requests != 1 {
+		t.Errorf("got status %d after %d requests", resp.StatusCode, requests)
+	}
+}
+
+func TestDoStopsOnCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	policy := Policy{MaxAttempts: 10, InitialBackoff: time.Hour, RetryableStatus: DefaultRetryableStatus}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := policy.Do(srv.Client(), req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := Policy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Multiplier: 2}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second} {
+		if got := policy.Backoff(attempt); got != want {
+			t.Errorf("attempt %d: got %v, want %v", attempt, got, want)
+		}
+	}
+
+	if d, ok := retryAfter("7"); !ok || d != 7*time.Second {
+		t.Errorf("Retry-After seconds: got %v, %v", d, ok)
+	}
+	if _, ok := retryAfter("soon"); ok {
+		t.Error("Retry-After garbage should be ignored")
+	}
+}
+
+func TestAttemptsOmitQuery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	var attempts []Attempt
+	policy := Policy{MaxAttempts: 2, OnAttempt: func(a Attempt) { attempts = append(attempts, a) }}
+	policy.RetryableStatus = DefaultRetryableStatus
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/translate?q=secret+text&key=k3y", nil)
+	resp, err := policy.Do(srv.Client(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// A transport error carries the URL as well.
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	req, _ = http.NewRequest(http.MethodGet, closed.URL+"/translate?q=secret+text&key=k3y", nil)
+	if _, err := policy.Do(http.DefaultClient, req); err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("got error %v, want one without the query", err)
+	}
+
+	if len(attempts) != 4 {
+		t.Fatalf("got %d attempts, want 4", len(attempts))
+	}
+	for _, a := range attempts {
+		if s := a.String(); strings.Contains(s, "secret") || strings.Contains(s, "k3y") || !strings.Contains(s, "/translate") {
+			t.Errorf("attempt %q must show the path but not the query", s)
+		}
+	}
+}
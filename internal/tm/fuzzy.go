@@ -0,0 +1,234 @@
+package tm
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Match is a memory entry similar to a looked up text.
+type Match struct {
+	Entry
+	// Score is the similarity in percent, 100 for identical token sequences.
+	Score float64
+}
+
+// Fuzzy returns up to limit entries whose source is at least threshold
+// percent similar to source, best match first. Similarity is the edit
+// distance between the normalized token sequences relative to the longer
+// one.
+//
+// Candidates are looked up in an index of the tokens of every source of
+// the language pair, built in memory on the first lookup, so only sources
+// sharing enough tokens with source are compared.
+func (m *Memory) Fuzzy(provider, from, to, source string, threshold float64, limit int) ([]Match, error) {
+	tokens := Tokens(source)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	bucket := bucketName(provider, from, to)
+	scores, err := m.similar(bucket, tokens, threshold)
+	if err != nil || len(scores) == 0 {
+		return nil, err
+	}
+
+	var matches []Match
+	err = m.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		for key, score := range scores {
+			var entry Entry
+			if err := json.Unmarshal(b.Get([]byte(key)), &entry); err != nil {
+				return err
+			}
+			matches = append(matches, Match{Entry: entry, Score: score})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Source < matches[j].Source
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// tokenIndex maps the tokens of the sources of a bucket to the keys of the
+// sources containing them.
+type tokenIndex struct {
+	tokens   map[string][]string
+	postings map[string][]posting
+}
+
+// posting is a source containing a token count times.
+type posting struct {
+	key   string
+	count int
+}
+
+// similar returns the similarity of the keys of bucket at least threshold
+// percent similar to tokens, building the token index of the bucket on
+// first use.
+func (m *Memory) similar(bucket []byte, tokens []string, threshold float64) (map[string]float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	index, ok := m.indexes[string(bucket)]
+	if !ok {
+		err := m.db.View(func(tx *bolt.Tx) error {
+			b := tx.Bucket(bucket)
+			if b == nil {
+				return nil
+			}
+			index = &tokenIndex{tokens: make(map[string][]string), postings: make(map[string][]posting)}
+			return b.ForEach(func(k, _ []byte) error {
+				index.add(string(k))
+				return nil
+			})
+		})
+		if err != nil || index == nil {
+			return nil, err
+		}
+		if m.indexes == nil {
+			m.indexes = make(map[string]*tokenIndex)
+		}
+		m.indexes[string(bucket)] = index
+	}
+
+	scores := make(map[string]float64)
+	for _, key := range index.candidates(tokens, threshold) {
+		if score := Similarity(tokens, index.tokens[key]); score >= threshold {
+			scores[key] = score
+		}
+	}
+	return scores, nil
+}
+
+// indexKeys adds the keys stored in bucket to its token index, if it was
+// built already.
+func (m *Memory) indexKeys(bucket string, keys []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if index, ok := m.indexes[bucket]; ok {
+		for _, key := range keys {
+			index.add(key)
+		}
+	}
+}
+
+func (x *tokenIndex) add(key string) {
+	if _, ok := x.tokens[key]; ok {
+		return
+	}
+	tokens := Tokens(key)
+	x.tokens[key] = tokens
+	for token, count := range tokenCounts(tokens) {
+		x.postings[token] = append(x.postings[token], posting{key: key, count: count})
+	}
+}
+
+// candidates returns the keys of the sources that may be at least
+// threshold percent similar to tokens. A source can't be more similar
+// than the share of its tokens, counted with repetitions, it has in
+// common with tokens, relative to the longer sequence.
+func (x *tokenIndex) candidates(tokens []string, threshold float64) []string {
+	common := make(map[string]int)
+	for token, count := range tokenCounts(tokens) {
+		for _, p := range x.postings[token] {
+			if p.count < count {
+				common[p.key] += p.count
+			} else {
+				common[p.key] += count
+			}
+		}
+	}
+
+	var keys []string
+	for key, n := range common {
+		longest := len(tokens)
+		if len(x.tokens[key]) > longest {
+			longest = len(x.tokens[key])
+		}
+		if 100*float64(n)/float64(longest) >= threshold {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func tokenCounts(tokens []string) map[string]int {
+	counts := make(map[string]int, len(tokens))
+	for _, token := range tokens {
+		counts[token]++
+	}
+	return counts
+}
+
+// Tokens splits text into lower case words with surrounding punctuation
+// removed.
+func Tokens(text string) []string {
+	var tokens []string
+	for _, field := range strings.Fields(Normalize(text)) {
+		token := strings.TrimFunc(strings.ToLower(field), func(r rune) bool {
+			return unicode.IsPunct(r) || unicode.IsSymbol(r)
+		})
+		if token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// Similarity returns how similar two token sequences are, in percent.
+func Similarity(a, b []string) float64 {
+	longest := len(a)
+	if len(b) > longest {
+		longest = len(b)
+	}
+	if longest == 0 {
+		return 100
+	}
+	return 100 * (1 - float64(editDistance(a, b))/float64(longest))
+}
+
+// editDistance is the Levenshtein distance between token sequences.
+func editDistance(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,161 @@
+// Package tm implements a persistent translation memory stored in an
+// embedded bbolt database. Entries are keyed by provider, source language,
+// target language and normalized source text.
+package tm
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Entry is a translation unit of the memory.
+type Entry struct {
+	Provider string    `json:"provider"`
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Source   string    `json:"source"`
+	Target   string    `json:"target"`
+	Created  time.Time `json:"created"`
+}
+
+// Memory is a translation memory backed by a bbolt database file. It is
+// safe for concurrent use.
+type Memory struct {
+	db *bolt.DB
+
+	// The token indexes of the buckets fuzzy lookups were made in, by
+	// bucket name.
+	mu      sync.Mutex
+	indexes map[string]*tokenIndex
+}
+
+// DefaultPath returns the location of the memory shared by the CLI and the
+// UI, inside the user cache directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "translate", "memory.db"), nil
+}
+
+// Open opens or creates the memory stored at path.
+func Open(path string) (*Memory, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		if errors.Is(err, bolt.ErrTimeout) {
+			return nil, errors.New("translation memory " + path + " is in use by another process")
+		}
+		return nil, err
+	}
+	return &Memory{db: db}, nil
+}
+
+// Close closes the database.
+func (m *Memory) Close() error {
+	return m.db.Close()
+}
+
+// Get returns the entry translating source with provider from one language
+// to another.
+func (m *Memory) Get(provider, from, to, source string) (Entry, bool, error) {
+	var entry Entry
+	var found bool
+	err := m.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName(provider, from, to))
+		if b == nil {
+			return nil
+		}
+		data := b.Get([]byte(Normalize(source)))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &entry)
+	})
+	return entry, found, err
+}
+
+// Put stores entries, replacing existing translations of the same source.
+// Entries without a creation date are stamped with the current time.
+func (m *Memory) Put(entries ...Entry) error {
+	keys := make(map[string][]string)
+	err := m.db.Update(func(tx *bolt.Tx) error {
+		for _, entry := range entries {
+			if entry.Created.IsZero() {
+				entry.Created = time.Now().UTC()
+			}
+			bucket := bucketName(entry.Provider, entry.From, entry.To)
+			b, err := tx.CreateBucketIfNotExists(bucket)
+			if err != nil {
+				return err
+			}
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			key := Normalize(entry.Source)
+			if err := b.Put([]byte(key), data); err != nil {
+				return err
+			}
+			keys[string(bucket)] = append(keys[string(bucket)], key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for bucket, bucketKeys := range keys {
+		m.indexKeys(bucket, bucketKeys)
+	}
+	return nil
+}
+
+// ForEach calls fn for every entry of the memory, grouped by provider and
+// language pair.
+func (m *Memory) ForEach(fn func(Entry) error) error {
+	return m.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(_ []byte, b *bolt.Bucket) error {
+			return b.ForEach(func(_, v []byte) error {
+				var entry Entry
+				if err := json.Unmarshal(v, &entry); err != nil {
+					return err
+				}
+				return fn(entry)
+			})
+		})
+	})
+}
+
+// Normalize returns the form of text used as key: Unicode NFC with leading
+// and trailing space removed and inner runs of white space collapsed.
+func Normalize(text string) string {
+	return strings.Join(strings.Fields(norm.NFC.String(text)), " ")
+}
+
+// LanguageCode returns the canonical form of a language tag used in keys,
+// e.g. "zh-TW" for "zh_tw". Script and region are kept, so variants such
+// as zh-CN and zh-TW have entries of their own. Tags that do not parse,
+// such as "auto", are lower cased.
+func LanguageCode(tag string) string {
+	if parsed, err := language.Parse(tag); err == nil {
+		return parsed.String()
+	}
+	return strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+}
+
+func bucketName(provider, from, to string) []byte {
+	return []byte(provider + "\x00" + LanguageCode(from) + "\x00" + LanguageCode(to))
+}
@@ -0,0 +1,147 @@
+package tm
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+type upperTranslator struct {
+	sent []string
+}
+
+func (t *upperTranslator) Name() string { return "upper" }
+
+func (t *upperTranslator) Translate(ctx context.Context, req translator.Request) ([]translator.Result, error) {
+	var results []translator.Result
+	for _, s := range req.Segments {
+		t.sent = append(t.sent, s)
+		results = append(results, translator.Result{Text: strings.ToUpper(s)})
+	}
+	return results, nil
+}
+
+func TestTranslatorUsesMemory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.db")
+	memory, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &upperTranslator{}
+	tr := Wrap(provider, memory)
+	req := translator.Request{From: "en", To: "fa", Segments: []string{"hello  world", "bye"}}
+	if _, err := tr.Translate(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	memory.Close()
+
+	// A new run with reformatted and new text only sends the new line.
+	memory, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer memory.Close()
+
+	provider.sent = nil
+	tr = Wrap(provider, memory)
+	req.Segments = []string{" hello world ", "new line", "bye"}
+	results, err := tr.Translate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.sent) != 1 || provider.sent[0] != "new line" {
+		t.Errorf("sent %q to the provider, want only the new line", provider.sent)
+	}
+	if results[0].Text != "HELLO  WORLD" || results[1].Text != "NEW LINE" || results[2].Text != "BYE" {
+		t.Errorf("unexpected results %+v", results)
+	}
+	if stats := tr.Stats(); stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("got stats %+v", stats)
+	}
+
+	if _, ok, _ := memory.Get("upper", "en", "de", "bye"); ok {
+		t.Error("entries must be separated by target language")
+	}
+}
+
+func TestFuzzy(t *testing.T) {
+	memory, err := Open(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer memory.Close()
+
+	err = memory.Put(
+		Entry{Provider: "upper", From: "en", To: "fa", Source: "Open the file in the editor.", Target: "A"},
+		Entry{Provider: "upper", From: "en", To: "fa", Source: "Close the window.", Target: "B"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := memory.Fuzzy("upper", "en", "fa", "open the file in an editor", DefaultFuzzyThreshold, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Target != "A" || int(matches[0].Score) != 83 {
+		t.Fatalf("unexpected matches %+v", matches)
+	}
+
+	// Entries stored after the first lookup are indexed too.
+	err = memory.Put(Entry{Provider: "upper", From: "en", To: "fa", Source: "Open the file in an editor!", Target: "C"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches, err = memory.Fuzzy("upper", "en", "fa", "open the file in an editor", DefaultFuzzyThreshold, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 || matches[0].Target != "C" || matches[0].Score != 100 || matches[1].Target != "A" {
+		t.Fatalf("unexpected matches after adding an entry %+v", matches)
+	}
+}
+
+func TestTranslatorFuzzy(t *testing.T) {
+	memory, err := Open(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer memory.Close()
+
+	err = memory.Put(Entry{Provider: "upper", From: "en", To: "fa", Source: "save all open files now", Target: "DRAFT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &upperTranslator{}
+	tr := Wrap(provider, memory)
+	tr.FuzzyThreshold = DefaultFuzzyThreshold
+	req := translator.Request{From: "en", To: "fa", Segments: []string{"save all open files"}}
+
+	results, err := tr.Translate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Text != "SAVE ALL OPEN FILES" || results[0].Fuzzy || len(results[0].Comments) != 1 {
+		t.Errorf("comment mode: unexpected result %+v", results[0])
+	}
+
+	tr.FuzzyDraft = true
+	req.Segments = []string{"save all open files today"}
+	provider.sent = nil
+	results, err = tr.Translate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The translation stored by the first call is now the closest match.
+	if len(provider.sent) != 0 || results[0].Text != "SAVE ALL OPEN FILES" || !results[0].Fuzzy {
+		t.Errorf("draft mode: sent %q, result %+v", provider.sent, results[0])
+	}
+	if stats := tr.Stats(); stats.Fuzzy != 1 {
+		t.Errorf("stats %+v, want one fuzzy draft", stats)
+	}
+}
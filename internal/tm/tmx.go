@@ -0,0 +1,229 @@
+package tm
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// tmxDate is the basic ISO 8601 format TMX uses for dates.
+const tmxDate = "20060102T150405Z"
+
+// providerProp is the type of the TMX property holding the provider of a
+// translation unit.
+const providerProp = "x-provider"
+
+type tmxDocument struct {
+	XMLName xml.Name  `xml:"tmx"`
+	Version string    `xml:"version,attr"`
+	Header  tmxHeader `xml:"header"`
+	Units   []tmxUnit `xml:"body>tu"`
+}
+
+type tmxHeader struct {
+	CreationTool        string `xml:"creationtool,attr"`
+	CreationToolVersion string `xml:"creationtoolversion,attr"`
+	SegType             string `xml:"segtype,attr"`
+	OTMF                string `xml:"o-tmf,attr"`
+	AdminLang           string `xml:"adminlang,attr"`
+	SrcLang             string `xml:"srclang,attr"`
+	DataType            string `xml:"datatype,attr"`
+	CreationDate        string `xml:"creationdate,attr,omitempty"`
+}
+
+type tmxUnit struct {
+	SrcLang      string       `xml:"srclang,attr,omitempty"`
+	CreationDate string       `xml:"creationdate,attr,omitempty"`
+	Props        []tmxProp    `xml:"prop"`
+	Variants     []tmxVariant `xml:"tuv"`
+}
+
+type tmxProp struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type tmxVariant struct {
+	Lang    string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	OldLang string `xml:"lang,attr,omitempty"`
+	Seg     tmxSeg `xml:"seg"`
+}
+
+func (v tmxVariant) lang() string {
+	if v.Lang != "" {
+		return v.Lang
+	}
+	return v.OldLang
+}
+
+// tmxSeg is the text of a segment. Inline elements wrapping text, such as
+// <hi>, keep their text while native codes (<bpt>, <ept>, <it>, <ph>,
+// <ut>) are dropped.
+type tmxSeg string
+
+func (s *tmxSeg) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var text strings.Builder
+	skip := 0
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch {
+			case skip > 0:
+				skip++
+			case isNativeCode(t.Name.Local):
+				skip = 1
+			}
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if t.Name == start.Name {
+				*s = tmxSeg(text.String())
+				return nil
+			}
+		case xml.CharData:
+			if skip == 0 {
+				text.Write(t)
+			}
+		}
+	}
+}
+
+func isNativeCode(name string) bool {
+	switch name {
+	case "bpt", "ept", "it", "ph", "ut":
+		return true
+	}
+	return false
+}
+
+// ReadTMX decodes the translation units of a TMX document. Every target
+// variant of a unit becomes an entry translating its source variant, with
+// the languages in the canonical form of LanguageCode. Units without an x-provider
+// property are attributed to provider.
+func ReadTMX(r io.Reader, provider string) ([]Entry, error) {
+	var doc tmxDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("tmx: %w", err)
+	}
+
+	var entries []Entry
+	for i, unit := range doc.Units {
+		srcLang := unit.SrcLang
+		if srcLang == "" {
+			srcLang = doc.Header.SrcLang
+		}
+		source, ok := unit.variant(srcLang)
+		if !ok {
+			return nil, fmt.Errorf("tmx: translation unit %d has no %s variant", i+1, srcLang)
+		}
+
+		created, err := parseTMXDate(unit.CreationDate)
+		if err != nil {
+			return nil, fmt.Errorf("tmx: translation unit %d: %w", i+1, err)
+		}
+
+		unitProvider := provider
+		for _, prop := range unit.Props {
+			if prop.Type == providerProp && prop.Value != "" {
+				unitProvider = prop.Value
+			}
+		}
+
+		from := LanguageCode(source.lang())
+		for _, target := range unit.Variants {
+			to := LanguageCode(target.lang())
+			if to == from || target.Seg == "" {
+				continue
+			}
+			entries = append(entries, Entry{
+				Provider: unitProvider,
+				From:     from,
+				To:       to,
+				Source:   string(source.Seg),
+				Target:   string(target.Seg),
+				Created:  created,
+			})
+		}
+	}
+	return entries, nil
+}
+
+// variant returns the variant in lang, or the first one when lang is empty
+// or "*all*".
+func (u tmxUnit) variant(lang string) (tmxVariant, bool) {
+	if len(u.Variants) == 0 {
+		return tmxVariant{}, false
+	}
+	if lang == "" || lang == "*all*" {
+		return u.Variants[0], true
+	}
+	for _, v := range u.Variants {
+		if strings.EqualFold(v.lang(), lang) {
+			return v, true
+		}
+	}
+	return tmxVariant{}, false
+}
+
+func parseTMXDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(tmxDate, value)
+	if err != nil {
+		return time.Time{}, errors.New("invalid creation date " + value)
+	}
+	return t, nil
+}
+
+// WriteTMX encodes entries as a TMX 1.4 document, one translation unit per
+// entry carrying its languages, creation date and provider.
+func WriteTMX(w io.Writer, entries []Entry) error {
+	doc := tmxDocument{
+		Version: "1.4",
+		Header: tmxHeader{
+			CreationTool:        "translate",
+			CreationToolVersion: "1",
+			SegType:             "paragraph",
+			OTMF:                "translate",
+			AdminLang:           "en",
+			SrcLang:             "*all*",
+			DataType:            "plaintext",
+			CreationDate:        time.Now().UTC().Format(tmxDate),
+		},
+	}
+	for _, entry := range entries {
+		unit := tmxUnit{
+			SrcLang: entry.From,
+			Props:   []tmxProp{{Type: providerProp, Value: entry.Provider}},
+			Variants: []tmxVariant{
+				{Lang: entry.From, Seg: tmxSeg(entry.Source)},
+				{Lang: entry.To, Seg: tmxSeg(entry.Target)},
+			},
+		}
+		if !entry.Created.IsZero() {
+			unit.CreationDate = entry.Created.UTC().Format(tmxDate)
+		}
+		doc.Units = append(doc.Units, unit)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
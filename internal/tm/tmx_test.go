@@ -0,0 +1,127 @@
+package tm
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleTMX = `<?xml version="1.0" encoding="UTF-8"?>
+<tmx version="1.4">
+  <header creationtool="other" creationtoolversion="2" segtype="sentence" o-tmf="other" adminlang="en-US" srclang="en" datatype="plaintext"/>
+  <body>
+    <tu creationdate="20210304T050607Z">
+      <prop type="x-provider">deepl</prop>
+      <tuv xml:lang="en"><seg>Press <bpt i="1">&lt;b&gt;</bpt>Save<ept i="1">&lt;/b&gt;</ept> now.</seg></tuv>
+      <tuv xml:lang="fa"><seg>اکنون ذخیره را بزنید.</seg></tuv>
+      <tuv xml:lang="ar"><seg>اضغط حفظ الآن.</seg></tuv>
+    </tu>
+    <tu>
+      <tuv xml:lang="fa"><seg>سلام</seg></tuv>
+      <tuv xml:lang="en"><seg>Hello</seg></tuv>
+    </tu>
+  </body>
+</tmx>`
+
+func TestReadTMX(t *testing.T) {
+	entries, err := ReadTMX(strings.NewReader(sampleTMX), "google")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3: %+v", len(entries), entries)
+	}
+
+	first := entries[0]
+	if first.Provider != "deepl" || first.From != "en" || first.To != "fa" || first.Source != "Press Save now." {
+		t.Errorf("unexpected first entry %+v", first)
+	}
+	if want := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC); !first.Created.Equal(want) {
+		t.Errorf("created %v, want %v", first.Created, want)
+	}
+	if entries[1].To != "ar" {
+		t.Errorf("second entry translates to %q, want ar", entries[1].To)
+	}
+	if last := entries[2]; last.Provider != "google" || last.From != "en" || last.Target != "سلام" {
+		t.Errorf("unexpected last entry %+v", last)
+	}
+}
+
+func TestTMXRoundTrip(t *testing.T) {
+	entries := []Entry{{
+		Provider: "openai",
+		From:     "en",
+		To:       "fa",
+		Source:   "Fish & chips <cheap>",
+		Target:   "ماهی و سیب‌زمینی",
+		Created:  time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC),
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteTMX(&buf, entries); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `xml:lang="en"`) {
+		t.Errorf("variants lack xml:lang:\n%s", buf.String())
+	}
+
+	got, err := ReadTMX(&buf, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != entries[0] {
+		t.Errorf("round trip gave %+v, want %+v", got, entries)
+	}
+}
+
+func TestReadTMXRegionTags(t *testing.T) {
+	const doc = `<tmx version="1.4">
+  <header creationtool="other" creationtoolversion="2" segtype="sentence" o-tmf="other" adminlang="en-US" srclang="en-us" datatype="plaintext"/>
+  <body>
+    <tu>
+      <tuv xml:lang="EN-US"><seg>Network</seg></tuv>
+      <tuv xml:lang="zh-CN"><seg>网络</seg></tuv>
+      <tuv xml:lang="zh_tw"><seg>網路</seg></tuv>
+    </tu>
+  </body>
+</tmx>`
+	entries, err := ReadTMX(strings.NewReader(doc), "google")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].From != "en-US" || entries[0].To != "zh-CN" || entries[1].To != "zh-TW" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	memory, err := Open(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer memory.Close()
+	if err := memory.Put(entries...); err != nil {
+		t.Fatal(err)
+	}
+	for to, want := range map[string]string{"zh-CN": "网络", "zh_cn": "网络", "zh-TW": "網路", "ZH-tw": "網路"} {
+		if entry, ok, err := memory.Get("google", "en_us", to, "Network"); err != nil || !ok || entry.Target != want {
+			t.Errorf("lookup into %s: got %+v, %v, %v, want %s", to, entry, ok, err, want)
+		}
+	}
+	if _, ok, _ := memory.Get("google", "en-US", "zh", "Network"); ok {
+		t.Error("zh shares the entries of a regional variant")
+	}
+
+	// Exporting and importing the entries keeps the variants apart.
+	var buf bytes.Buffer
+	if err := WriteTMX(&buf, entries); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadTMX(&buf, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != entries[0] || got[1] != entries[1] {
+		t.Errorf("round trip gave %+v, want %+v", got, entries)
+	}
+}
@@ -0,0 +1,145 @@
+package tm
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+// DefaultFuzzyThreshold is the minimum similarity, in percent, of a fuzzy
+// match.
+const DefaultFuzzyThreshold = 75
+
+// Stats counts how many segments were answered by the memory.
+type Stats struct {
+	Hits   int64
+	Fuzzy  int64
+	Misses int64
+}
+
+// Translator consults a Memory before calling the wrapped provider and
+// stores every new translation.
+type Translator struct {
+	translator.Translator
+	// FuzzyThreshold enables fuzzy lookups of segments missing from the
+	// memory: the best entry at least this similar, in percent, is attached
+	// to the result as a translator comment. Zero disables fuzzy matching.
+	FuzzyThreshold float64
+	// FuzzyDraft uses the fuzzy match as the translation, marked fuzzy,
+	// instead of calling the provider.
+	FuzzyDraft bool
+
+	memory *Memory
+	hits   atomic.Int64
+	fuzzy  atomic.Int64
+	misses atomic.Int64
+}
+
+// Wrap returns a Translator backed by memory.
+func Wrap(t translator.Translator, memory *Memory) *Translator {
+	return &Translator{Translator: t, memory: memory}
+}
+
+// Stats returns the hit and miss counters.
+func (t *Translator) Stats() Stats {
+	return Stats{Hits: t.hits.Load(), Fuzzy: t.fuzzy.Load(), Misses: t.misses.Load()}
+}
+
+// Translate implements translator.Translator. Only segments missing from the
+// memory are sent to the provider, in a single request.
+func (t *Translator) Translate(ctx context.Context, req translator.Request) ([]translator.Result, error) {
+	provider := t.Name()
+	results := make([]translator.Result, len(req.Segments))
+
+	var missing []int
+	matches := make(map[int]Match)
+	for i, segment := range req.Segments {
+		entry, ok, err := t.memory.Get(provider, req.From, req.To, segment)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			t.hits.Add(1)
+			results[i] = translator.Result{Text: entry.Target, Provider: provider}
+			continue
+		}
+
+		match, ok, err := t.bestMatch(provider, req.From, req.To, segment)
+		if err != nil {
+			return nil, err
+		}
+		if ok && t.FuzzyDraft {
+			t.fuzzy.Add(1)
+			results[i] = translator.Result{
+				Text:     match.Target,
+				Provider: provider,
+				Fuzzy:    true,
+				Comments: []string{match.comment()},
+			}
+			continue
+		}
+		if ok {
+			matches[i] = match
+		}
+		t.misses.Add(1)
+		missing = append(missing, i)
+	}
+
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	sub := req
+	sub.Segments = make([]string, len(missing))
+	for j, i := range missing {
+		sub.Segments[j] = req.Segments[i]
+	}
+	translated, err := t.Translator.Translate(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	if len(translated) != len(missing) {
+		return nil, fmt.Errorf("tm: %w", translator.ErrSegmentCount)
+	}
+
+	entries := make([]Entry, 0, len(translated))
+	for j, result := range translated {
+		if match, ok := matches[missing[j]]; ok {
+			result.Comments = append(result.Comments, match.comment())
+		}
+		results[missing[j]] = result
+		if result.Text == "" {
+			continue
+		}
+		entries = append(entries, Entry{
+			Provider: provider,
+			From:     req.From,
+			To:       req.To,
+			Source:   sub.Segments[j],
+			Target:   result.Text,
+		})
+	}
+	if err := t.memory.Put(entries...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// bestMatch returns the most similar memory entry above the fuzzy threshold.
+func (t *Translator) bestMatch(provider, from, to, source string) (Match, bool, error) {
+	if t.FuzzyThreshold <= 0 {
+		return Match{}, false, nil
+	}
+	matches, err := t.memory.Fuzzy(provider, from, to, source, t.FuzzyThreshold, 1)
+	if err != nil || len(matches) == 0 {
+		return Match{}, false, err
+	}
+	return matches[0], true, nil
+}
+
+// comment describes the match for a PO translator comment.
+func (m Match) comment() string {
+	return fmt.Sprintf("TM %.0f%%: %s => %s", m.Score, m.Source, m.Target)
+}
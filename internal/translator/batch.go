@@ -0,0 +1,85 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// Delimiter separates segments packed into one text for providers without a
+// native batch API. Segments are single lines, so a line break never occurs
+// inside one, and translation engines keep line breaks in place.
+const Delimiter = "\n"
+
+// JoinSegments packs segments into one text.
+func JoinSegments(segments []string) string {
+	return strings.Join(segments, Delimiter)
+}
+
+// SplitJoined splits the translation of a text built by JoinSegments back
+// into n segments. Empty segments at either end are kept, while a single
+// extra trailing delimiter some engines add is ignored. It reports false
+// when the translation does not hold exactly n segments.
+func SplitJoined(text string, n int) ([]string, bool) {
+	parts := strings.Split(text, Delimiter)
+	if len(parts) == n+1 && parts[n] == "" {
+		parts = parts[:n]
+	}
+	if len(parts) != n {
+		return nil, false
+	}
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	return parts, true
+}
+
+// Chunks splits segments into consecutive groups of at most maxCount
+// segments and maxChars characters in total, for providers limiting what a
+// request may hold. A segment longer than maxChars is a group of its own.
+// A limit of zero or less is not enforced.
+func Chunks(segments []string, maxCount, maxChars int) [][]string {
+	var chunks [][]string
+	start, chars := 0, 0
+	for i, segment := range segments {
+		n := utf8.RuneCountInString(segment)
+		full := maxCount > 0 && i-start == maxCount
+		if i > start && (full || maxChars > 0 && chars+n > maxChars) {
+			chunks = append(chunks, segments[start:i])
+			start, chars = i, 0
+		}
+		chars += n
+	}
+	if start < len(segments) {
+		chunks = append(chunks, segments[start:])
+	}
+	return chunks
+}
+
+// TranslateBatch translates the segments of req, typically consecutive
+// lines, in as few requests as the provider allows. When the provider
+// answers a different number of results than segments, e.g. because a
+// language model merged two lines, every segment is translated on its own
+// instead.
+func TranslateBatch(ctx context.Context, t Translator, req Request) ([]Result, error) {
+	req.Batch = len(req.Segments) > 1
+	results, err := t.Translate(ctx, req)
+	if err == nil && len(results) != len(req.Segments) {
+		err = ErrSegmentCount
+	}
+	if !req.Batch || !errors.Is(err, ErrSegmentCount) {
+		return results, err
+	}
+
+	results = make([]Result, len(req.Segments))
+	for i, segment := range req.Segments {
+		one := req
+		one.Batch = false
+		one.Segments = []string{segment}
+		if results[i], err = TranslateOne(ctx, t, one); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
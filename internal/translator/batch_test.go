@@ -0,0 +1,77 @@
+package translator
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// mergingTranslator answers batches with one result fewer than segments, as
+// a language model merging two lines would.
+type mergingTranslator struct {
+	requests int
+}
+
+func (t *mergingTranslator) Name() string { return "merging" }
+
+func (t *mergingTranslator) Translate(ctx context.Context, req Request) ([]Result, error) {
+	t.requests++
+	var results []Result
+	for _, s := range req.Segments {
+		results = append(results, Result{Text: strings.ToUpper(s)})
+	}
+	if req.Batch {
+		return results[1:], nil
+	}
+	return results, nil
+}
+
+func TestTranslateBatchFallsBackPerSegment(t *testing.T) {
+	provider := &mergingTranslator{}
+	results, err := TranslateBatch(context.Background(), provider, Request{Segments: []string{"a", "b", "c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider.requests != 4 {
+		t.Errorf("sent %d requests, want the batch and one per segment", provider.requests)
+	}
+	if len(results) != 3 || results[0].Text != "A" || results[2].Text != "C" {
+		t.Errorf("unexpected results %+v", results)
+	}
+}
+
+func TestSplitJoined(t *testing.T) {
+	parts, ok := SplitJoined(JoinSegments([]string{"one ", "two", "three"})+"\n", 3)
+	if !ok || strings.Join(parts, "|") != "one|two|three" {
+		t.Errorf("got %q, %v", parts, ok)
+	}
+	if _, ok := SplitJoined("one two\nthree", 3); ok {
+		t.Error("split a text holding two segments into three")
+	}
+
+	// Empty segments at the edges are kept.
+	parts, ok = SplitJoined(JoinSegments([]string{"", "two", ""}), 3)
+	if !ok || strings.Join(parts, "|") != "|two|" {
+		t.Errorf("empty edge segments: got %q, %v", parts, ok)
+	}
+	parts, ok = SplitJoined(JoinSegments([]string{"", "two", ""})+"\n", 3)
+	if !ok || strings.Join(parts, "|") != "|two|" {
+		t.Errorf("empty edge segments and a trailing delimiter: got %q, %v", parts, ok)
+	}
+}
+
+func TestChunks(t *testing.T) {
+	segments := []string{"aa", "bb", "cc", "dddddd", "e", "f"}
+	got := Chunks(segments, 2, 5)
+	want := [][]string{{"aa", "bb"}, {"cc"}, {"dddddd"}, {"e", "f"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := Chunks(segments, 0, 0); len(got) != 1 || len(got[0]) != len(segments) {
+		t.Errorf("unlimited chunks %q", got)
+	}
+	if got := Chunks(nil, 2, 5); len(got) != 0 {
+		t.Errorf("chunks of no segments %q", got)
+	}
+}
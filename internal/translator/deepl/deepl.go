@@ -0,0 +1,163 @@
+// Package deepl implements a translator.Translator for the official DeepL
+// API. It registers itself as the "deepl" provider.
+package deepl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mshafiee/translate/internal/retry"
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+// Name is the registry name of this provider.
+const Name = "deepl"
+
+const (
+	// DefaultBaseURL is the endpoint of paid DeepL API plans.
+	DefaultBaseURL = "https://api.deepl.com"
+	// FreeBaseURL is the endpoint of DeepL API Free, selected automatically
+	// for keys ending in ":fx".
+	FreeBaseURL = "https://api-free.deepl.com"
+)
+
+const (
+	// maxBatchSize is the maximum number of texts DeepL accepts in one
+	// request.
+	maxBatchSize = 50
+	// maxRequestChars keeps the text of a request, at up to four bytes a
+	// character, below the 128 KiB request body limit.
+	maxRequestChars = 30000
+)
+
+func init() {
+	translator.Register(Name, func(cfg translator.Config) (translator.Translator, error) {
+		return New(cfg)
+	})
+}
+
+// Client talks to the DeepL /v2/translate endpoint.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	formality  string
+	glossaryID string
+	httpClient *http.Client
+	retry      retry.Policy
+}
+
+// New creates a DeepL client. Supported options are "formality" (default,
+// more, less, prefer_more, prefer_less) and "glossary_id".
+func New(cfg translator.Config) (*Client, error) {
+	if cfg.APIKey == "" {
+		return nil, errors.New("deepl: an API key is required")
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+		if strings.HasSuffix(cfg.APIKey, ":fx") {
+			baseURL = FreeBaseURL
+		}
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     cfg.APIKey,
+		formality:  cfg.Option("formality", ""),
+		glossaryID: cfg.Option("glossary_id", ""),
+		httpClient: cfg.Client(),
+		retry:      cfg.RetryPolicy(),
+	}, nil
+}
+
+// Name implements translator.Translator.
+func (c *Client) Name() string {
+	return Name
+}
+
+// MaxLength implements translator.LengthLimiter. DeepL limits a request
+// body to 128 KiB; segments are kept well below it.
+func (c *Client) MaxLength() int {
+	return 30000
+}
+
+type translateRequest struct {
+	Text       []string `json:"text"`
+	SourceLang string   `json:"source_lang,omitempty"`
+	TargetLang string   `json:"target_lang"`
+	Formality  string   `json:"formality,omitempty"`
+	GlossaryID string   `json:"glossary_id,omitempty"`
+}
+
+type translateResponse struct {
+	Translations []struct {
+		DetectedSourceLanguage string `json:"detected_source_language"`
+		Text                   string `json:"text"`
+	} `json:"translations"`
+}
+
+// Translate implements translator.Translator. Segments are sent as the
+// text array, split into requests of at most maxBatchSize texts and
+// maxRequestChars characters.
+func (c *Client) Translate(ctx context.Context, req translator.Request) ([]translator.Result, error) {
+	if c.glossaryID != "" && languageCode(req.From) == "" {
+		return nil, errors.New("deepl: a glossary requires an explicit source language")
+	}
+
+	results := make([]translator.Result, 0, len(req.Segments))
+	for _, segments := range translator.Chunks(req.Segments, maxBatchSize, maxRequestChars) {
+		batch, err := c.translateBatch(ctx, req.From, req.To, segments)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, batch...)
+	}
+	return results, nil
+}
+
+func (c *Client) translateBatch(ctx context.Context, from, to string, segments []string) ([]translator.Result, error) {
+	body := translateRequest{
+		Text:       segments,
+		SourceLang: languageCode(from),
+		TargetLang: languageCode(to),
+		Formality:  c.formality,
+		GlossaryID: c.glossaryID,
+	}
+
+	httpReq, err := translator.NewJSONRequest(ctx, http.MethodPost, c.baseURL+"/v2/translate", body)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "DeepL-Auth-Key "+c.apiKey)
+
+	var resp translateResponse
+	if err := translator.DoJSON(c.httpClient, c.retry, Name, httpReq, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Translations) != len(segments) {
+		return nil, fmt.Errorf("deepl: %w", translator.ErrSegmentCount)
+	}
+
+	results := make([]translator.Result, len(resp.Translations))
+	for i, t := range resp.Translations {
+		results[i] = translator.Result{
+			Text:             t.Text,
+			DetectedLanguage: strings.ToLower(t.DetectedSourceLanguage),
+			Provider:         Name,
+		}
+	}
+	return results, nil
+}
+
+// languageCode converts an ISO 639-1 code to the upper case form DeepL
+// expects. "auto" and empty codes let DeepL detect the language.
+func languageCode(code string) string {
+	if code == "" || code == "auto" {
+		return ""
+	}
+	return strings.ToUpper(code)
+}
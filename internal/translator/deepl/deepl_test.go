@@ -0,0 +1,112 @@
+package deepl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+func TestTranslate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/translate" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "DeepL-Auth-Key key:fx" {
+			t.Errorf("unexpected authorization %q", got)
+		}
+
+		var req translateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		want := translateRequest{
+			Text:       []string{"Hello", "World"},
+			SourceLang: "EN",
+			TargetLang: "DE",
+			Formality:  "more",
+			GlossaryID: "g-1",
+		}
+		if len(req.Text) != 2 || req.Text[0] != want.Text[0] || req.SourceLang != want.SourceLang ||
+			req.TargetLang != want.TargetLang || req.Formality != want.Formality || req.GlossaryID != want.GlossaryID {
+			t.Errorf("got request %+v, want %+v", req, want)
+		}
+
+		w.Write([]byte(`{"translations":[{"detected_source_language":"EN","text":"Hallo"},{"detected_source_language":"EN","text":"Welt"}]}`))
+	}))
+	defer srv.Close()
+
+	tr, err := translator.New(Name, translator.Config{
+		BaseURL: srv.URL,
+		APIKey:  "key:fx",
+		Options: map[string]string{"formality": "more", "glossary_id": "g-1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := tr.Translate(context.Background(), translator.Request{From: "en", To: "de", Segments: []string{"Hello", "World"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Text != "Hallo" || results[1].Text != "Welt" || results[0].DetectedLanguage != "en" {
+		t.Errorf("unexpected results %+v", results)
+	}
+}
+
+func TestFreeKeySelectsFreeEndpoint(t *testing.T) {
+	c, err := New(translator.Config{APIKey: "key:fx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.baseURL != FreeBaseURL {
+		t.Errorf("got base URL %s, want %s", c.baseURL, FreeBaseURL)
+	}
+}
+
+func TestTranslateSplitsRequests(t *testing.T) {
+	var sizes []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req translateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, len(req.Text))
+
+		var resp translateResponse
+		resp.Translations = make([]struct {
+			DetectedSourceLanguage string `json:"detected_source_language"`
+			Text                   string `json:"text"`
+		}, len(req.Text))
+		for i, text := range req.Text {
+			resp.Translations[i].Text = strings.ToUpper(text)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	tr, err := translator.New(Name, translator.Config{BaseURL: srv.URL, APIKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	segments := make([]string, 2*maxBatchSize+20)
+	for i := range segments {
+		segments[i] = fmt.Sprintf("line %d", i)
+	}
+	results, err := tr.Translate(context.Background(), translator.Request{From: "en", To: "de", Segments: segments})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sizes) != 3 || sizes[0] != maxBatchSize || sizes[2] != 20 {
+		t.Errorf("got requests of %v texts", sizes)
+	}
+	if len(results) != len(segments) || results[2*maxBatchSize].Text != "LINE 100" {
+		t.Errorf("unexpected results: %d", len(results))
+	}
+}
@@ -0,0 +1,96 @@
+// Package google adapts the unofficial Google translate_a/single endpoint
+// implemented by the gtranslate package to the translator.Translator
+// interface. It registers itself as the "google" provider.
+package google
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/mshafiee/translate/internal/gtranslate"
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+// Name is the registry name of this provider.
+const Name = "google"
+
+func init() {
+	translator.Register(Name, New)
+}
+
+// Translator translates segments through a gtranslate.Client.
+type Translator struct {
+	client *gtranslate.Client
+}
+
+// New creates a Google translator. The "host" option selects the Google
+// domain, e.g. google.cn, while a base URL replaces the endpoint entirely.
+func New(cfg translator.Config) (translator.Translator, error) {
+	client := gtranslate.NewClient(cfg.Option("host", ""))
+	client.BaseURL = cfg.BaseURL
+	client.HTTPClient = cfg.HTTPClient
+	if cfg.Retry != nil {
+		client.Retry = *cfg.Retry
+		if !client.Retry.Retryable(http.StatusForbidden) {
+			client.Retry.RetryableStatus = append([]int{http.StatusForbidden}, client.Retry.RetryableStatus...)
+		}
+	}
+	return &Translator{client: client}, nil
+}
+
+// Name implements translator.Translator.
+func (t *Translator) Name() string {
+	return Name
+}
+
+// Translate implements translator.Translator. The endpoint only accepts one
+// text per request, so segments are sent one after another, unless the
+// request allows batching: then they are sent as one text separated by
+// translator.Delimiter, falling back to one request per segment when the
+// translation does not split back into as many segments.
+func (t *Translator) Translate(ctx context.Context, req translator.Request) ([]translator.Result, error) {
+	if req.Batch && len(req.Segments) > 1 {
+		resp, err := t.lookup(ctx, translator.JoinSegments(req.Segments), req)
+		if err != nil {
+			return nil, err
+		}
+		if texts, ok := translator.SplitJoined(resp.Translation(), len(req.Segments)); ok {
+			results := make([]translator.Result, len(texts))
+			for i, text := range texts {
+				results[i] = translator.Result{Text: text, DetectedLanguage: resp.SourceLanguage, Provider: Name}
+			}
+			return results, nil
+		}
+	}
+
+	results := make([]translator.Result, 0, len(req.Segments))
+	for _, segment := range req.Segments {
+		resp, err := t.lookup(ctx, segment, req)
+		if err != nil {
+			return nil, err
+		}
+
+		texts := resp.Texts()
+		result := translator.Result{
+			Text:             texts[0],
+			Alternatives:     texts[1:],
+			DetectedLanguage: resp.SourceLanguage,
+			Provider:         Name,
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (t *Translator) lookup(ctx context.Context, text string, req translator.Request) (*gtranslate.Response, error) {
+	resp, err := t.client.TranslateDetailed(ctx, text, gtranslate.TranslationParams{
+		From: req.From,
+		To:   req.To,
+	})
+	var statusErr *gtranslate.StatusError
+	if errors.As(err, &statusErr) {
+		return nil, &translator.HTTPError{Provider: Name, StatusCode: statusErr.StatusCode, Message: statusErr.Body}
+	}
+	return resp, err
+}
@@ -0,0 +1,30 @@
+package google
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mshafiee/translate/internal/gtranslate/gtranslatetest"
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+func TestTranslateBatchJoinsSegments(t *testing.T) {
+	srv := gtranslatetest.NewServer()
+	defer srv.Close()
+	srv.Record("en", "es", "Hello\nBye", `[[["Hola\n","Hello\n",null,null,10],["Adiós","Bye",null,null,10]],null,"en"]`)
+
+	tr, err := New(translator.Config{BaseURL: srv.URL, HTTPClient: srv.Client()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := translator.TranslateBatch(context.Background(), tr, translator.Request{From: "en", To: "es", Segments: []string{"Hello", "Bye"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Text != "Hola" || results[1].Text != "Adiós" {
+		t.Errorf("unexpected results %+v", results)
+	}
+	if srv.Requests() != 1 {
+		t.Errorf("sent %d requests, want 1", srv.Requests())
+	}
+}
@@ -0,0 +1,80 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mshafiee/translate/internal/retry"
+)
+
+// HTTPError is returned by HTTP based providers when the service answers
+// with a non-2xx status code.
+type HTTPError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+func (e *HTTPError) Error() string {
+	msg := strings.TrimSpace(e.Message)
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("%s: status %d: %s", e.Provider, e.StatusCode, msg)
+}
+
+// NewJSONRequest builds a request with body encoded as JSON. A nil body sends
+// no payload.
+func NewJSONRequest(ctx context.Context, method, url string, body interface{}) (*http.Request, error) {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, payload)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
+
+// DoJSON sends req with client, retrying according to policy, and decodes a
+// successful JSON answer into out. Non-2xx answers are returned as *HTTPError
+// carrying the response body.
+func DoJSON(client *http.Client, policy retry.Policy, provider string, req *http.Request, out interface{}) error {
+	resp, err := policy.Do(client, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &HTTPError{Provider: provider, StatusCode: resp.StatusCode, Message: string(data)}
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s: decoding response: %w", provider, err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+// Package libre implements a translator.Translator for the LibreTranslate
+// REST API, typically a self-hosted instance. It registers itself as the
+// "libretranslate" provider.
+package libre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mshafiee/translate/internal/retry"
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+// Name is the registry name of this provider.
+const Name = "libretranslate"
+
+// DefaultBaseURL is used when no base URL is configured.
+const DefaultBaseURL = "http://localhost:5000"
+
+func init() {
+	translator.Register(Name, func(cfg translator.Config) (translator.Translator, error) {
+		return New(cfg)
+	})
+}
+
+// Language is an entry of the /languages endpoint.
+type Language struct {
+	Code    string   `json:"code"`
+	Name    string   `json:"name"`
+	Targets []string `json:"targets"`
+}
+
+// Detection is an entry of the /detect endpoint.
+type Detection struct {
+	Language   string  `json:"language"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Client talks to a LibreTranslate server.
+type Client struct {
+	baseURL      string
+	apiKey       string
+	alternatives int
+	httpClient   *http.Client
+	retry        retry.Policy
+}
+
+// New creates a LibreTranslate client. The "alternatives" option requests
+// that many alternative translations per segment from servers supporting it.
+func New(cfg translator.Config) (*Client, error) {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	alternatives, err := strconv.Atoi(cfg.Option("alternatives", "0"))
+	if err != nil {
+		return nil, errors.New("libretranslate: alternatives option must be a number")
+	}
+
+	return &Client{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		apiKey:       cfg.APIKey,
+		alternatives: alternatives,
+		httpClient:   cfg.Client(),
+		retry:        cfg.RetryPolicy(),
+	}, nil
+}
+
+// Name implements translator.Translator.
+func (c *Client) Name() string {
+	return Name
+}
+
+// MaxLength implements translator.LengthLimiter. Public LibreTranslate
+// instances reject longer texts.
+func (c *Client) MaxLength() int {
+	return 5000
+}
+
+type translateRequest struct {
+	Q            []string `json:"q"`
+	Source       string   `json:"source"`
+	Target       string   `json:"target"`
+	Format       string   `json:"format"`
+	Alternatives int      `json:"alternatives,omitempty"`
+	APIKey       string   `json:"api_key,omitempty"`
+}
+
+type translateResponse struct {
+	TranslatedText   []string    `json:"translatedText"`
+	Alternatives     [][]string  `json:"alternatives"`
+	DetectedLanguage []Detection `json:"detectedLanguage"`
+}
+
+// Translate implements translator.Translator. All segments are sent in a
+// single request.
+func (c *Client) Translate(ctx context.Context, req translator.Request) ([]translator.Result, error) {
+	if len(req.Segments) == 0 {
+		return nil, nil
+	}
+
+	from := req.From
+	if from == "" {
+		from = "auto"
+	}
+
+	body := translateRequest{
+		Q:            req.Segments,
+		Source:       from,
+		Target:       req.To,
+		Format:       "text",
+		Alternatives: c.alternatives,
+		APIKey:       c.apiKey,
+	}
+	httpReq, err := translator.NewJSONRequest(ctx, http.MethodPost, c.baseURL+"/translate", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp translateResponse
+	if err := translator.DoJSON(c.httpClient, c.retry, Name, httpReq, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.TranslatedText) != len(req.Segments) {
+		return nil, fmt.Errorf("libretranslate: %w", translator.ErrSegmentCount)
+	}
+
+	results := make([]translator.Result, len(resp.TranslatedText))
+	for i, text := range resp.TranslatedText {
+		results[i] = translator.Result{Text: text, Provider: Name}
+		if i < len(resp.Alternatives) {
+			results[i].Alternatives = resp.Alternatives[i]
+		}
+		if i < len(resp.DetectedLanguage) {
+			results[i].DetectedLanguage = resp.DetectedLanguage[i].Language
+		}
+	}
+	return results, nil
+}
+
+// Detect returns the candidate languages of text, most likely first.
+func (c *Client) Detect(ctx context.Context, text string) ([]Detection, error) {
+	body := struct {
+		Q      string `json:"q"`
+		APIKey string `json:"api_key,omitempty"`
+	}{text, c.apiKey}
+
+	req, err := translator.NewJSONRequest(ctx, http.MethodPost, c.baseURL+"/detect", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var detections []Detection
+	if err := translator.DoJSON(c.httpClient, c.retry, Name, req, &detections); err != nil {
+		return nil, err
+	}
+	return detections, nil
+}
+
+// Languages returns the languages supported by the server.
+func (c *Client) Languages(ctx context.Context) ([]Language, error) {
+	req, err := translator.NewJSONRequest(ctx, http.MethodGet, c.baseURL+"/languages", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var languages []Language
+	if err := translator.DoJSON(c.httpClient, c.retry, Name, req, &languages); err != nil {
+		return nil, err
+	}
+	return languages, nil
+}
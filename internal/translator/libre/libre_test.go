@@ -0,0 +1,112 @@
+package libre
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/translate", func(w http.ResponseWriter, r *http.Request) {
+		var req translateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.APIKey != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":"Invalid API key"}`))
+			return
+		}
+		if req.Source != "en" || req.Target != "es" || req.Format != "text" {
+			t.Errorf("unexpected request %+v", req)
+		}
+		var translated []string
+		for _, q := range req.Q {
+			translated = append(translated, strings.ToUpper(q))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"translatedText": translated})
+	})
+	mux.HandleFunc("/detect", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"confidence":92.0,"language":"en"}]`))
+	})
+	mux.HandleFunc("/languages", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"code":"en","name":"English","targets":["es","fa"]}]`))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestTranslate(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	tr, err := translator.New(Name, translator.Config{BaseURL: srv.URL + "/", APIKey: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := tr.Translate(context.Background(), translator.Request{
+		From:     "en",
+		To:       "es",
+		Segments: []string{"hello", "world"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Text)
+	}
+	if want := []string{"HELLO", "WORLD"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestTranslateBadKey(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	c, err := New(translator.Config{BaseURL: srv.URL, APIKey: "wrong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Translate(context.Background(), translator.Request{From: "en", To: "es", Segments: []string{"hello"}})
+	var httpErr *translator.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 HTTPError, got %v", err)
+	}
+}
+
+func TestDetectAndLanguages(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	c, err := New(translator.Config{BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	detections, err := c.Detect(context.Background(), "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(detections) != 1 || detections[0].Language != "en" {
+		t.Errorf("unexpected detections %+v", detections)
+	}
+
+	languages, err := c.Languages(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(languages) != 1 || languages[0].Code != "en" || len(languages[0].Targets) != 2 {
+		t.Errorf("unexpected languages %+v", languages)
+	}
+}
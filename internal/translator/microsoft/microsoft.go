@@ -0,0 +1,151 @@
+// Package microsoft implements a translator.Translator for the Microsoft
+// Translator Text API v3. It registers itself as the "microsoft" provider.
+package microsoft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mshafiee/translate/internal/retry"
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+// Name is the registry name of this provider.
+const Name = "microsoft"
+
+// DefaultBaseURL is the global Translator endpoint.
+const DefaultBaseURL = "https://api.cognitive.microsofttranslator.com"
+
+const (
+	// maxBatchSize is the maximum number of elements accepted in one
+	// request.
+	maxBatchSize = 1000
+	// maxRequestChars is the maximum number of characters of all elements
+	// of a request.
+	maxRequestChars = 50000
+)
+
+func init() {
+	translator.Register(Name, func(cfg translator.Config) (translator.Translator, error) {
+		return New(cfg)
+	})
+}
+
+// Client talks to the /translate endpoint.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	region     string
+	httpClient *http.Client
+	retry      retry.Policy
+}
+
+// New creates a Microsoft Translator client. The "region" option is
+// required for regional and multi-service resources.
+func New(cfg translator.Config) (*Client, error) {
+	if cfg.APIKey == "" {
+		return nil, errors.New("microsoft: an API key is required")
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     cfg.APIKey,
+		region:     cfg.Option("region", ""),
+		httpClient: cfg.Client(),
+		retry:      cfg.RetryPolicy(),
+	}, nil
+}
+
+// Name implements translator.Translator.
+func (c *Client) Name() string {
+	return Name
+}
+
+// MaxLength implements translator.LengthLimiter. Segments are kept to a
+// fifth of the maxRequestChars a request may hold, so several fit in one.
+func (c *Client) MaxLength() int {
+	return maxRequestChars / 5
+}
+
+type textElement struct {
+	Text string `json:"Text"`
+}
+
+type translateResponse []struct {
+	DetectedLanguage struct {
+		Language string  `json:"language"`
+		Score    float64 `json:"score"`
+	} `json:"detectedLanguage"`
+	Translations []struct {
+		Text string `json:"text"`
+		To   string `json:"to"`
+	} `json:"translations"`
+}
+
+// Translate implements translator.Translator. Segments are sent as the body
+// array, split into requests of at most maxBatchSize elements and
+// maxRequestChars characters.
+func (c *Client) Translate(ctx context.Context, req translator.Request) ([]translator.Result, error) {
+	results := make([]translator.Result, 0, len(req.Segments))
+	for _, segments := range translator.Chunks(req.Segments, maxBatchSize, maxRequestChars) {
+		batch, err := c.translateBatch(ctx, req.From, req.To, segments)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, batch...)
+	}
+	return results, nil
+}
+
+func (c *Client) translateBatch(ctx context.Context, from, to string, segments []string) ([]translator.Result, error) {
+	query := url.Values{}
+	query.Set("api-version", "3.0")
+	query.Set("to", to)
+	if from != "" && from != "auto" {
+		query.Set("from", from)
+	}
+
+	body := make([]textElement, len(segments))
+	for i, segment := range segments {
+		body[i] = textElement{Text: segment}
+	}
+
+	httpReq, err := translator.NewJSONRequest(ctx, http.MethodPost, c.baseURL+"/translate?"+query.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Ocp-Apim-Subscription-Key", c.apiKey)
+	if c.region != "" {
+		httpReq.Header.Set("Ocp-Apim-Subscription-Region", c.region)
+	}
+
+	var resp translateResponse
+	if err := translator.DoJSON(c.httpClient, c.retry, Name, httpReq, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp) != len(segments) {
+		return nil, fmt.Errorf("microsoft: %w", translator.ErrSegmentCount)
+	}
+
+	results := make([]translator.Result, len(resp))
+	for i, item := range resp {
+		if len(item.Translations) == 0 {
+			return nil, errors.New("microsoft: response element has no translation")
+		}
+		results[i] = translator.Result{
+			Text:             item.Translations[0].Text,
+			DetectedLanguage: item.DetectedLanguage.Language,
+			Provider:         Name,
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,111 @@
+package microsoft
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+func TestTranslate(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		q := r.URL.Query()
+		if r.URL.Path != "/translate" || q.Get("api-version") != "3.0" || q.Get("to") != "fa" || q.Has("from") {
+			t.Errorf("unexpected URL %s", r.URL)
+		}
+		if r.Header.Get("Ocp-Apim-Subscription-Key") != "key" || r.Header.Get("Ocp-Apim-Subscription-Region") != "westeurope" {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+
+		var body []textElement
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		var resp []string
+		for _, e := range body {
+			resp = append(resp, fmt.Sprintf(`{"detectedLanguage":{"language":"en","score":1.0},"translations":[{"text":%q,"to":"fa"}]}`, strings.ToUpper(e.Text)))
+		}
+		w.Write([]byte("[" + strings.Join(resp, ",") + "]"))
+	}))
+	defer srv.Close()
+
+	tr, err := translator.New(Name, translator.Config{
+		BaseURL: srv.URL,
+		APIKey:  "key",
+		Options: map[string]string{"region": "westeurope"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	segments := make([]string, maxBatchSize+1)
+	for i := range segments {
+		segments[i] = fmt.Sprintf("line %d", i)
+	}
+	results, err := tr.Translate(context.Background(), translator.Request{From: "auto", To: "fa", Segments: segments})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("got %d requests, want 2", requests)
+	}
+	if len(results) != len(segments) || results[maxBatchSize].Text != "LINE 1000" || results[0].DetectedLanguage != "en" {
+		t.Errorf("unexpected results: %d, %+v", len(results), results[0])
+	}
+}
+
+func TestTranslateLimitsRequestCharacters(t *testing.T) {
+	var sizes []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body []textElement
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, len(body))
+		var resp []string
+		for range body {
+			resp = append(resp, `{"translations":[{"text":"x","to":"fa"}]}`)
+		}
+		w.Write([]byte("[" + strings.Join(resp, ",") + "]"))
+	}))
+	defer srv.Close()
+
+	tr, err := translator.New(Name, translator.Config{BaseURL: srv.URL, APIKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	segment := strings.Repeat("a", maxRequestChars/5)
+	segments := []string{segment, segment, segment, segment, segment, segment}
+	if _, err := tr.Translate(context.Background(), translator.Request{From: "en", To: "fa", Segments: segments}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sizes) != 2 || sizes[0] != 5 || sizes[1] != 1 {
+		t.Errorf("got requests of %v elements, want [5 1]", sizes)
+	}
+}
+
+func TestTranslateSegmentCountMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"translations":[{"text":"x","to":"fa"}]}]`))
+	}))
+	defer srv.Close()
+
+	tr, err := translator.New(Name, translator.Config{BaseURL: srv.URL, APIKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = tr.Translate(context.Background(), translator.Request{From: "en", To: "fa", Segments: []string{"a", "b"}})
+	if !errors.Is(err, translator.ErrSegmentCount) {
+		t.Errorf("got error %v, want %v", err, translator.ErrSegmentCount)
+	}
+}
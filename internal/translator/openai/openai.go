@@ -0,0 +1,243 @@
+// Package openai implements a translator.Translator on top of any
+// OpenAI-compatible /v1/chat/completions endpoint such as OpenAI, Ollama, vLLM
+// or the llama.cpp server. It registers itself as the "openai" provider.
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/mshafiee/translate/internal/retry"
+	"github.com/mshafiee/translate/internal/translator"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// Name is the registry name of this provider.
+const Name = "openai"
+
+// DefaultBaseURL is used when no base URL is configured.
+const DefaultBaseURL = "https://api.openai.com/v1"
+
+// DefaultModel is used when no model option is configured.
+const DefaultModel = "gpt-4o-mini"
+
+// DefaultSystemPrompt is the system prompt template used when none is
+// configured. It is executed with a PromptData value.
+const DefaultSystemPrompt = `You are a professional translator. Translate text from {{.FromName}} to {{.ToName}}.
+The user message contains {{.Count}} numbered lines to translate, possibly surrounded by context lines from the same paragraph.
+Use the context to keep the translation coherent but do not translate it.
+Answer with a JSON array of exactly {{.Count}} strings, the translation of each numbered line in order, and nothing else.`
+
+func init() {
+	translator.Register(Name, func(cfg translator.Config) (translator.Translator, error) {
+		return New(cfg)
+	})
+}
+
+// PromptData is passed to the system prompt template.
+type PromptData struct {
+	From     string
+	To       string
+	FromName string
+	ToName   string
+	Count    int
+}
+
+// Client calls a chat completions endpoint.
+type Client struct {
+	baseURL      string
+	apiKey       string
+	model        string
+	temperature  float64
+	systemPrompt *template.Template
+	httpClient   *http.Client
+	retry        retry.Policy
+}
+
+// New creates a chat completions client. Supported options are "model",
+// "temperature" and "system_prompt", a text/template executed with
+// PromptData.
+func New(cfg translator.Config) (*Client, error) {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	temperature, err := strconv.ParseFloat(cfg.Option("temperature", "0"), 64)
+	if err != nil {
+		return nil, errors.New("openai: temperature option must be a number")
+	}
+
+	systemPrompt, err := template.New("system").Parse(cfg.Option("system_prompt", DefaultSystemPrompt))
+	if err != nil {
+		return nil, fmt.Errorf("openai: parsing system prompt: %w", err)
+	}
+
+	return &Client{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		apiKey:       cfg.APIKey,
+		model:        cfg.Option("model", DefaultModel),
+		temperature:  temperature,
+		systemPrompt: systemPrompt,
+		httpClient:   cfg.Client(),
+		retry:        cfg.RetryPolicy(),
+	}, nil
+}
+
+// Name implements translator.Translator.
+func (c *Client) Name() string {
+	return Name
+}
+
+// MaxLength implements translator.LengthLimiter. Longer segments risk
+// exceeding the output token limit of the model.
+func (c *Client) MaxLength() int {
+	return 4000
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []message `json:"messages"`
+	Temperature float64   `json:"temperature"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message message `json:"message"`
+	} `json:"choices"`
+}
+
+// Translate implements translator.Translator. All segments and their
+// context are sent in a single chat completion.
+func (c *Client) Translate(ctx context.Context, req translator.Request) ([]translator.Result, error) {
+	if len(req.Segments) == 0 {
+		return nil, nil
+	}
+
+	var system bytes.Buffer
+	err := c.systemPrompt.Execute(&system, PromptData{
+		From:     req.From,
+		To:       req.To,
+		FromName: languageName(req.From),
+		ToName:   languageName(req.To),
+		Count:    len(req.Segments),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("openai: executing system prompt: %w", err)
+	}
+
+	body := chatRequest{
+		Model: c.model,
+		Messages: []message{
+			{Role: "system", Content: system.String()},
+			{Role: "user", Content: userMessage(req)},
+		},
+		Temperature: c.temperature,
+	}
+	httpReq, err := translator.NewJSONRequest(ctx, http.MethodPost, c.baseURL+"/chat/completions", body)
+	if err != nil {
+		return nil, err
+	}
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	var resp chatResponse
+	if err := translator.DoJSON(c.httpClient, c.retry, Name, httpReq, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, errors.New("openai: response contains no choices")
+	}
+
+	lines, err := parseLines(resp.Choices[0].Message.Content, len(req.Segments))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]translator.Result, len(lines))
+	for i, line := range lines {
+		results[i] = translator.Result{Text: line, Provider: Name}
+	}
+	return results, nil
+}
+
+// userMessage lays out the context and the numbered segments.
+func userMessage(req translator.Request) string {
+	var b strings.Builder
+	if len(req.Before) > 0 {
+		b.WriteString("Context before:\n")
+		for _, line := range req.Before {
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("Lines to translate:\n")
+	for i, segment := range req.Segments {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, segment)
+	}
+
+	if len(req.After) > 0 {
+		b.WriteString("\nContext after:\n")
+		for _, line := range req.After {
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// parseLines extracts count translations from the model answer. Models
+// sometimes wrap the JSON array in a markdown code fence, and a lone segment
+// may be answered with plain text.
+func parseLines(content string, count int) ([]string, error) {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(content, "```json")
+		content = strings.TrimPrefix(content, "```")
+		content = strings.TrimSuffix(content, "```")
+		content = strings.TrimSpace(content)
+	}
+
+	var lines []string
+	if err := json.Unmarshal([]byte(content), &lines); err != nil {
+		if count == 1 {
+			return []string{content}, nil
+		}
+		// Translating the lines one by one may still succeed.
+		return nil, fmt.Errorf("openai: answer is not a JSON array of strings (%v): %w", err, translator.ErrSegmentCount)
+	}
+	if len(lines) != count {
+		return nil, fmt.Errorf("openai: got %d translations for %d lines: %w", len(lines), count, translator.ErrSegmentCount)
+	}
+	return lines, nil
+}
+
+func languageName(code string) string {
+	if code == "" || code == "auto" {
+		return "the detected source language"
+	}
+	tag, err := language.Parse(code)
+	if err != nil {
+		return code
+	}
+	if name := display.English.Languages().Name(tag); name != "" {
+		return name
+	}
+	return code
+}
@@ -0,0 +1,91 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mshafiee/translate/internal/translator"
+)
+
+func TestTranslate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer key" {
+			t.Errorf("unexpected authorization %q", got)
+		}
+
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Model != "llama3" || req.Temperature != 0.3 {
+			t.Errorf("unexpected request %+v", req)
+		}
+		if req.Messages[0].Content != "English -> Spanish (2)" {
+			t.Errorf("unexpected system prompt %q", req.Messages[0].Content)
+		}
+		user := req.Messages[1].Content
+		for _, want := range []string{"Context before:\nIt was late.", "1. Hello", "2. World", "Context after:\nGoodbye."} {
+			if !strings.Contains(user, want) {
+				t.Errorf("user message %q does not contain %q", user, want)
+			}
+		}
+
+		json.NewEncoder(w).Encode(chatResponse{Choices: []struct {
+			Message message `json:"message"`
+		}{{Message: message{Role: "assistant", Content: "```json\n[\"Hola\", \"Mundo\"]\n```"}}}})
+	}))
+	defer srv.Close()
+
+	tr, err := translator.New(Name, translator.Config{
+		BaseURL: srv.URL + "/v1",
+		APIKey:  "key",
+		Options: map[string]string{
+			"model":         "llama3",
+			"temperature":   "0.3",
+			"system_prompt": "{{.FromName}} -> {{.ToName}} ({{.Count}})",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := tr.Translate(context.Background(), translator.Request{
+		From:     "en",
+		To:       "es",
+		Segments: []string{"Hello", "World"},
+		Before:   []string{"It was late."},
+		After:    []string{"Goodbye."},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Text)
+	}
+	if want := []string{"Hola", "Mundo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseLines(t *testing.T) {
+	if _, err := parseLines(`["one"]`, 2); !errors.Is(err, translator.ErrSegmentCount) {
+		t.Errorf("count mismatch: got error %v, want %v", err, translator.ErrSegmentCount)
+	}
+	if _, err := parseLines("Hola\nmundo", 2); !errors.Is(err, translator.ErrSegmentCount) {
+		t.Errorf("plain answer for two lines: got error %v, want %v", err, translator.ErrSegmentCount)
+	}
+	lines, err := parseLines("Hola mundo", 1)
+	if err != nil || !reflect.DeepEqual(lines, []string{"Hola mundo"}) {
+		t.Errorf("plain answer for one line: got %v, %v", lines, err)
+	}
+}
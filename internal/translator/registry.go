@@ -0,0 +1,116 @@
+package translator
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/mshafiee/translate/internal/retry"
+)
+
+// Config carries provider specific settings. Unknown options are ignored by
+// providers that do not use them.
+type Config struct {
+	// BaseURL overrides the provider endpoint, e.g. a self-hosted server.
+	BaseURL string
+	// APIKey authenticates against the provider.
+	APIKey string
+	// HTTPClient is used for all requests; http.DefaultClient when nil.
+	HTTPClient *http.Client
+	// Retry is the retry policy; retry.DefaultPolicy() when nil.
+	Retry   *retry.Policy
+	Options map[string]string
+}
+
+// RetryPolicy returns the configured retry policy or retry.DefaultPolicy().
+func (c Config) RetryPolicy() retry.Policy {
+	if c.Retry != nil {
+		return *c.Retry
+	}
+	return retry.DefaultPolicy()
+}
+
+// Client returns the configured HTTP client or http.DefaultClient.
+func (c Config) Client() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// Option returns the named option or def when it is not set.
+func (c Config) Option(name, def string) string {
+	if v, ok := c.Options[name]; ok && v != "" {
+		return v
+	}
+	return def
+}
+
+// Factory creates a Translator from a Config.
+type Factory func(cfg Config) (Translator, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a provider available under name. It is meant to be called
+// from the init function of the provider package and panics on duplicates.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic("translator: Register factory is nil")
+	}
+	if _, dup := registry[name]; dup {
+		panic("translator: Register called twice for provider " + name)
+	}
+	registry[name] = factory
+}
+
+// New creates the provider registered under name. Segments longer than the
+// provider accepts, or than the "max_length" option, are split into chunks
+// by a Splitter.
+func New(name string, cfg Config) (Translator, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown translation provider %q (available: %v)", name, Names())
+	}
+	t, err := factory(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	max := 0
+	if l, ok := t.(LengthLimiter); ok {
+		max = l.MaxLength()
+	}
+	if v := cfg.Option("max_length", ""); v != "" {
+		if max, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid max_length option %q", v)
+		}
+	}
+	if max > 0 {
+		t = SplitLong(t, max)
+	}
+	return t, nil
+}
+
+// Names returns the sorted names of all registered providers.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	var names []string
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
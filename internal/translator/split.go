@@ -0,0 +1,105 @@
+package translator
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mshafiee/translate/internal/gtranslate"
+	"golang.org/x/text/language"
+)
+
+// LengthLimiter is implemented by providers that reject segments longer than
+// a number of characters.
+type LengthLimiter interface {
+	MaxLength() int
+}
+
+// Splitter splits segments longer than a maximum length into chunks at
+// sentence boundaries, translates the chunks along with the other segments
+// and joins their translations back into one result.
+type Splitter struct {
+	Translator
+	max int
+}
+
+// SplitLong wraps t so no segment longer than max characters reaches it.
+func SplitLong(t Translator, max int) *Splitter {
+	return &Splitter{Translator: t, max: max}
+}
+
+// Translate implements Translator.
+func (s *Splitter) Translate(ctx context.Context, req Request) ([]Result, error) {
+	// counts[i] is the number of chunks segment i was split into.
+	counts := make([]int, len(req.Segments))
+	var chunks []string
+	split := false
+	for i, segment := range req.Segments {
+		parts := gtranslate.SplitIntoChunks(segment, s.max)
+		counts[i] = len(parts)
+		chunks = append(chunks, parts...)
+		split = split || len(parts) > 1
+	}
+	if !split {
+		return s.Translator.Translate(ctx, req)
+	}
+
+	sub := req
+	sub.Segments = chunks
+	translated, err := s.Translator.Translate(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	if len(translated) != len(chunks) {
+		return nil, ErrSegmentCount
+	}
+
+	results := make([]Result, len(req.Segments))
+	for i, n := range counts {
+		parts := translated[:n]
+		translated = translated[n:]
+		if n == 1 {
+			results[i] = parts[0]
+			continue
+		}
+		results[i] = join(parts, wordSeparator(req.To))
+	}
+	return results, nil
+}
+
+// scriptsWithoutSpaces are the scripts whose languages do not separate
+// words, or sentences, with spaces.
+var scriptsWithoutSpaces = map[string]bool{
+	"Hans": true, "Hant": true, "Jpan": true,
+	"Thai": true, "Laoo": true, "Khmr": true, "Mymr": true, "Tibt": true,
+}
+
+// wordSeparator returns the separator between the translated chunks of a
+// segment in language lang: nothing for languages written without spaces,
+// such as Chinese, Japanese or Thai, a space otherwise.
+func wordSeparator(lang string) string {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return " "
+	}
+	if script, _ := tag.Script(); scriptsWithoutSpaces[script.String()] {
+		return ""
+	}
+	return " "
+}
+
+// join combines the translations of the chunks of a segment with sep.
+// Alternatives are per chunk and cannot be combined, so they are dropped.
+func join(parts []Result, sep string) Result {
+	texts := make([]string, len(parts))
+	result := Result{
+		DetectedLanguage: parts[0].DetectedLanguage,
+		Provider:         parts[0].Provider,
+	}
+	for i, part := range parts {
+		texts[i] = part.Text
+		result.Fuzzy = result.Fuzzy || part.Fuzzy
+		result.Comments = append(result.Comments, part.Comments...)
+	}
+	result.Text = strings.Join(texts, sep)
+	return result
+}
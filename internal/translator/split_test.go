@@ -0,0 +1,66 @@
+package translator
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type upperTranslator struct {
+	sent []string
+}
+
+func (t *upperTranslator) Name() string { return "upper" }
+
+func (t *upperTranslator) Translate(ctx context.Context, req Request) ([]Result, error) {
+	var results []Result
+	for _, s := range req.Segments {
+		t.sent = append(t.sent, s)
+		results = append(results, Result{Text: strings.ToUpper(s), Alternatives: []string{s}})
+	}
+	return results, nil
+}
+
+func TestSplitterRejoinsChunks(t *testing.T) {
+	provider := &upperTranslator{}
+	Register("test-upper", func(cfg Config) (Translator, error) { return provider, nil })
+	tr, err := New("test-upper", Config{Options: map[string]string{"max_length": "20"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := Request{From: "en", To: "fa", Segments: []string{"short", "First sentence. Second sentence."}}
+	results, err := tr.Translate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"short", "First sentence.", "Second sentence."}; strings.Join(provider.sent, "|") != strings.Join(want, "|") {
+		t.Errorf("sent %q, want %q", provider.sent, want)
+	}
+	if len(results) != 2 || results[0].Text != "SHORT" || len(results[0].Alternatives) != 1 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if results[1].Text != "FIRST SENTENCE. SECOND SENTENCE." || results[1].Alternatives != nil {
+		t.Errorf("unexpected joined result %+v", results[1])
+	}
+}
+
+func TestSplitterJoinsWithoutSpaces(t *testing.T) {
+	tr := SplitLong(&upperTranslator{}, 20)
+	for to, want := range map[string]string{
+		"ja":      "FIRST SENTENCE.SECOND SENTENCE.",
+		"zh-TW":   "FIRST SENTENCE.SECOND SENTENCE.",
+		"th":      "FIRST SENTENCE.SECOND SENTENCE.",
+		"ko":      "FIRST SENTENCE. SECOND SENTENCE.",
+		"invalid": "FIRST SENTENCE. SECOND SENTENCE.",
+	} {
+		results, err := tr.Translate(context.Background(), Request{From: "en", To: to, Segments: []string{"First sentence. Second sentence."}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if results[0].Text != want {
+			t.Errorf("into %s: got %q, want %q", to, results[0].Text, want)
+		}
+	}
+}
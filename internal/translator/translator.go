@@ -0,0 +1,104 @@
+// Package translator defines the interface implemented by every translation
+// backend and a registry the front-ends use to pick one by name.
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mshafiee/translate/internal/gtranslate"
+)
+
+// ErrSegmentCount is returned, possibly wrapped, when a provider answers a
+// different number of results than segments sent.
+var ErrSegmentCount = errors.New("provider returned a different number of results than segments sent")
+
+// Request is a batch of segments to translate from one language to another.
+type Request struct {
+	From     string
+	To       string
+	Segments []string
+	// Before and After hold lines surrounding the segments. They are not
+	// translated but let context aware providers keep a paragraph coherent.
+	Before []string
+	After  []string
+	// Batch lets providers without a native batch API pack the segments
+	// into a single text separated by Delimiter, at the cost of per segment
+	// metadata such as alternatives.
+	Batch bool
+}
+
+// Result is the translation of a single segment together with the metadata
+// the provider returned for it.
+type Result struct {
+	Text             string
+	Alternatives     []string
+	DetectedLanguage string
+	Provider         string
+	// Fuzzy marks a draft that was not produced for this exact segment and
+	// needs review.
+	Fuzzy bool
+	// Comments are notes for the translator, e.g. similar translations
+	// found in the translation memory.
+	Comments []string
+}
+
+// Translator translates a batch of segments. Implementations return exactly
+// one Result per segment, in the same order.
+type Translator interface {
+	Name() string
+	Translate(ctx context.Context, req Request) ([]Result, error)
+}
+
+// TranslateText is a convenience wrapper translating a single segment.
+func TranslateText(ctx context.Context, t Translator, text, from, to string) (Result, error) {
+	return TranslateOne(ctx, t, Request{From: from, To: to, Segments: []string{text}})
+}
+
+// TranslateOne sends a request holding a single segment and returns its
+// result.
+func TranslateOne(ctx context.Context, t Translator, req Request) (Result, error) {
+	results, err := t.Translate(ctx, req)
+	if err != nil {
+		return Result{}, err
+	}
+	if len(results) != 1 {
+		return Result{}, ErrSegmentCount
+	}
+	return results[0], nil
+}
+
+// TranslateSentences splits text into sentences, translates them in one batch
+// and formats every sentence as "original: translation alternatives...",
+// mirroring gtranslate.SentenceWithParams for any provider.
+func TranslateSentences(ctx context.Context, t Translator, text, from, to string) ([]string, error) {
+	sentences := gtranslate.SplitIntoSentences(text)
+	if len(sentences) == 0 {
+		return nil, nil
+	}
+
+	results, err := t.Translate(ctx, Request{From: from, To: to, Segments: sentences})
+	if err != nil {
+		return nil, err
+	}
+	if len(results) != len(sentences) {
+		return nil, ErrSegmentCount
+	}
+
+	var sentenceMeaning []string
+	for i, s := range sentences {
+		// Create a map to keep track of which strings we have seen
+		seen := make(map[string]bool)
+
+		sentence := fmt.Sprintf("%s: ", s)
+		for _, alt := range append([]string{results[i].Text}, results[i].Alternatives...) {
+			if !seen[alt] {
+				sentence += fmt.Sprintf("%s ", alt)
+				seen[alt] = true
+			}
+		}
+		sentenceMeaning = append(sentenceMeaning, sentence)
+	}
+	return sentenceMeaning, nil
+}
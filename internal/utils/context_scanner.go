@@ -0,0 +1,103 @@
+package utils
+
+import "strings"
+
+// LineScanner is the subset of bufio.Scanner used to read input files line by
+// line.
+type LineScanner interface {
+	Scan() bool
+	Text() string
+	Err() error
+}
+
+// ContextScanner reads lines from a LineScanner and exposes, for the current
+// line, up to n neighbouring lines of the same paragraph. Paragraphs are
+// separated by blank lines.
+type ContextScanner struct {
+	scanner LineScanner
+	n       int
+	before  []string
+	ahead   []string
+	current string
+	started bool
+	eof     bool
+}
+
+// NewContextScanner returns a ContextScanner keeping n lines of context on
+// each side.
+func NewContextScanner(scanner LineScanner, n int) *ContextScanner {
+	return &ContextScanner{scanner: scanner, n: n}
+}
+
+// Scan advances to the next line.
+func (c *ContextScanner) Scan() bool {
+	if c.started {
+		if isBlank(c.current) {
+			c.before = c.before[:0]
+		} else {
+			c.before = append(c.before, c.current)
+			if len(c.before) > c.n {
+				c.before = c.before[1:]
+			}
+		}
+	}
+
+	c.fill(c.n + 1)
+	if len(c.ahead) == 0 {
+		return false
+	}
+
+	c.started = true
+	c.current = c.ahead[0]
+	c.ahead = c.ahead[1:]
+	return true
+}
+
+// Text returns the current line.
+func (c *ContextScanner) Text() string {
+	return c.current
+}
+
+// Before returns the lines of the current paragraph preceding the current
+// line.
+func (c *ContextScanner) Before() []string {
+	if isBlank(c.current) {
+		return nil
+	}
+	return append([]string(nil), c.before...)
+}
+
+// After returns the lines of the current paragraph following the current
+// line.
+func (c *ContextScanner) After() []string {
+	if isBlank(c.current) {
+		return nil
+	}
+	var after []string
+	for _, line := range c.ahead {
+		if isBlank(line) {
+			break
+		}
+		after = append(after, line)
+	}
+	return after
+}
+
+// Err returns the first error of the underlying scanner.
+func (c *ContextScanner) Err() error {
+	return c.scanner.Err()
+}
+
+func (c *ContextScanner) fill(size int) {
+	for !c.eof && len(c.ahead) < size {
+		if !c.scanner.Scan() {
+			c.eof = true
+			break
+		}
+		c.ahead = append(c.ahead, c.scanner.Text())
+	}
+}
+
+func isBlank(line string) bool {
+	return len(strings.TrimSpace(line)) == 0
+}
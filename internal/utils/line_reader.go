@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// LineReader reads lines of any length, unlike bufio.Scanner which gives up
+// on lines longer than its token limit. Like bufio.ScanLines it strips the
+// trailing end-of-line marker, including a carriage return before the
+// newline. It implements LineScanner.
+type LineReader struct {
+	reader *bufio.Reader
+	line   []byte
+	err    error
+}
+
+// NewLineReader returns a LineReader reading from r.
+func NewLineReader(r io.Reader) *LineReader {
+	return &LineReader{reader: bufio.NewReader(r)}
+}
+
+// Scan advances to the next line. It returns false at the end of the input
+// or on a read error.
+func (l *LineReader) Scan() bool {
+	if l.err != nil {
+		return false
+	}
+
+	l.line = l.line[:0]
+	for {
+		chunk, err := l.reader.ReadSlice('\n')
+		l.line = append(l.line, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			l.err = err
+			if len(l.line) == 0 {
+				return false
+			}
+		}
+		break
+	}
+
+	l.line = bytes.TrimSuffix(l.line, []byte("\n"))
+	l.line = bytes.TrimSuffix(l.line, []byte("\r"))
+	return true
+}
+
+// Text returns the current line.
+func (l *LineReader) Text() string {
+	return string(l.line)
+}
+
+// Err returns the first error other than io.EOF.
+func (l *LineReader) Err() error {
+	if l.err == io.EOF {
+		return nil
+	}
+	return l.err
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLineReaderLongLines(t *testing.T) {
+	long := strings.Repeat("word ", 100000)
+	input := "first\r\n" + long + "\n\nlast"
+
+	var lines []string
+	reader := NewLineReader(strings.NewReader(input))
+	for reader.Scan() {
+		lines = append(lines, reader.Text())
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(lines) != 4 || lines[0] != "first" || lines[1] != long || lines[2] != "" || lines[3] != "last" {
+		t.Errorf("got %d lines, want first, the long line, a blank line and last", len(lines))
+	}
+}
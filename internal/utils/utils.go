@@ -1,14 +1,12 @@
 package utils
 
 import (
-	"bufio"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"os"
 	"sort"
-	"strconv"
 	"strings"
 )
 
@@ -20,7 +18,7 @@ func CountLines(filename string) (int, error) {
 	defer file.Close()
 
 	var lines int
-	scanner := bufio.NewScanner(file)
+	scanner := NewLineReader(file)
 	for scanner.Scan() {
 		lines++
 	}
@@ -41,121 +39,6 @@ func GetCSVFieldCount(csvString string) (int, error) {
 	return len(records[0]), nil
 }
 
-func GetMaxCommas(filePath string) (int, error) {
-	maxCommas := 0
-	file, err := os.Open(filePath)
-	if err != nil {
-		return 0, err
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		commaCount, err := GetCSVFieldCount(line)
-		if err != nil {
-			return 0, err
-		}
-		if commaCount > maxCommas {
-			maxCommas = commaCount
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return 0, err
-	}
-
-	return maxCommas, nil
-}
-
-func AddCommasToFile(inputFilePath, outputFilePath string) error {
-	// Get max number of commas in a line
-	maxCommas, err := GetMaxCommas(inputFilePath)
-	if err != nil {
-		return err
-	}
-
-	// Open input and output files
-	inputFile, err := os.Open(inputFilePath)
-	if err != nil {
-		return err
-	}
-	defer inputFile.Close()
-
-	outputFile, err := os.Create(outputFilePath)
-	if err != nil {
-		return err
-	}
-	defer outputFile.Close()
-
-	// Iterate over each line in input file
-	scanner := bufio.NewScanner(inputFile)
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Add commas if line has less than maxCommas
-		commaCount, err := GetCSVFieldCount(line)
-		if err != nil {
-			return err
-		}
-		if commaCount < maxCommas {
-			diff := maxCommas - commaCount
-			line += strings.Repeat(",", diff)
-		}
-
-		// Write modified line to output file
-		_, err = fmt.Fprintln(outputFile, line)
-		if err != nil {
-			return err
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func NumericalSortCSV(inputFile string, outputFile string) error {
-	// Open input file
-	f, err := os.Open(inputFile)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	// Read CSV data
-	r := csv.NewReader(f)
-	lines, err := r.ReadAll()
-	if err != nil {
-		return err
-	}
-
-	// Sort lines based on numerical values of first column
-	sort.Slice(lines, func(i, j int) bool {
-		num1, _ := strconv.ParseFloat(lines[i][0], 64)
-		num2, _ := strconv.ParseFloat(lines[j][0], 64)
-		return num1 < num2
-	})
-
-	// Open output file
-	out, err := os.Create(outputFile)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	// Write sorted lines to output file
-	w := csv.NewWriter(out)
-	err = w.WriteAll(lines)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
 func SortCSVByFirstColumn(filename string) error {
 	// Open the CSV file
 	file, err := os.Open(filename)
@@ -237,58 +120,6 @@ func ExtractColumn(inputFile string, outputFile string, columnNumber int) error
 	return nil
 }
 
-func ExtractColumnWithEmptyRows(inputFile string, outputFile string, columnNumber int) error {
-	// Open input file
-	f, err := os.Open(inputFile)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	// Read CSV data
-	r := csv.NewReader(f)
-	lines, err := r.ReadAll()
-	if err != nil {
-		return err
-	}
-
-	// Extract column values
-	var column []string
-	prevNum := -1 // previous numerical value of first cell
-	for _, line := range lines {
-		if len(line) >= columnNumber {
-			num, err := strconv.Atoi(line[0])
-			if err != nil {
-				return err
-			}
-			if num-prevNum > 1 {
-				column = append(column, "") // add empty row
-			}
-			column = append(column, line[columnNumber-1])
-			prevNum = num
-		}
-	}
-
-	// Open output file
-	out, err := os.Create(outputFile)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	// Write column values to output file
-	w := csv.NewWriter(out)
-	for _, value := range column {
-		err = w.Write([]string{value})
-		if err != nil {
-			return err
-		}
-	}
-	w.Flush()
-
-	return nil
-}
-
 // TranslationDataType Define the data structure
 type TranslationDataType struct {
 	Row     string `json:"row"`